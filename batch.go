@@ -0,0 +1,169 @@
+package typescript
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// Source is a single named script to transpile as part of a batch.
+type Source struct {
+	// Name is used as the file name passed to the compiler, unless the transpile options set a file name.
+	Name   string
+	Script io.Reader
+}
+
+// BatchResult is the outcome of transpiling a single Source in a batch.
+type BatchResult struct {
+	Name   string
+	Result *TranspileResult
+	// Err is the error returned while transpiling the source. Sources that were not transpiled because the
+	// batch was cancelled have the context's error.
+	Err error
+}
+
+// BatchProgress describes the progress of a batch after a source has been transpiled.
+type BatchProgress struct {
+	// Completed is the number of sources that have been transpiled so far, including this one.
+	Completed int
+	Total     int
+	// Index is the index of the source that was just transpiled in the batch inputs.
+	Index int
+	Name  string
+	Err   error
+}
+
+// BatchConfig configures TranspileBatch.
+type BatchConfig struct {
+	// Concurrency is the number of sources transpiled in parallel, each with its own runtime. Defaults to
+	// the number of CPUs.
+	Concurrency int
+	// Progress is called after each source is transpiled. Calls are serialized, but are made from the
+	// worker goroutines so the callback should return quickly.
+	Progress func(BatchProgress)
+	// TranspileOptions are applied when transpiling each source.
+	TranspileOptions []TranspileOptionFunc
+}
+
+// BatchOptionFunc is a function that mutates the BatchConfig.
+type BatchOptionFunc func(cfg *BatchConfig)
+
+// WithBatchConcurrency sets the number of sources transpiled in parallel.
+func WithBatchConcurrency(n int) BatchOptionFunc {
+	return func(cfg *BatchConfig) {
+		cfg.Concurrency = n
+	}
+}
+
+// WithBatchProgress sets a callback that is called after each source is transpiled.
+func WithBatchProgress(fn func(BatchProgress)) BatchOptionFunc {
+	return func(cfg *BatchConfig) {
+		cfg.Progress = fn
+	}
+}
+
+// WithBatchTranspileOptions adds options that are used when transpiling each source.
+func WithBatchTranspileOptions(opts ...TranspileOptionFunc) BatchOptionFunc {
+	return func(cfg *BatchConfig) {
+		cfg.TranspileOptions = append(cfg.TranspileOptions, opts...)
+	}
+}
+
+// TranspileBatch transpiles the inputs in parallel, spreading them across a number of Transpilers that share a
+// single registry so that the compiler is only compiled once. Results are returned in the same order as the
+// inputs, with per-source errors reported in each result rather than failing the batch. If the context is
+// cancelled, all workers stop promptly and the context's error is returned along with the results, where the
+// sources that were not transpiled have the context's error. An error is also returned if a transpiler could
+// not be created, for example if the typescript version is not registered.
+func TranspileBatch(ctx context.Context, inputs []Source, opts ...BatchOptionFunc) ([]BatchResult, error) {
+	batch := &BatchConfig{}
+	for _, fn := range opts {
+		fn(batch)
+	}
+	if batch.Concurrency <= 0 {
+		batch.Concurrency = runtime.NumCPU()
+	}
+	if batch.Concurrency > len(inputs) {
+		batch.Concurrency = len(inputs)
+	}
+	// Resolve the registry once so that every transpiler shares it
+	cfg := NewDefaultConfig()
+	for _, fn := range batch.TranspileOptions {
+		fn(cfg)
+	}
+	transpilerOpts := append([]TranspileOptionFunc{WithRegistry(cfg.Registry)}, batch.TranspileOptions...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]BatchResult, len(inputs))
+	for i, input := range inputs {
+		results[i].Name = input.Name
+	}
+	indexes := make(chan int)
+	var (
+		wg        sync.WaitGroup
+		lock      sync.Mutex
+		completed int
+		createErr error
+	)
+	for w := 0; w < batch.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t, err := NewTranspilerCtx(ctx, transpilerOpts...)
+			if err != nil {
+				lock.Lock()
+				if createErr == nil && ctx.Err() == nil {
+					createErr = fmt.Errorf("creating transpiler: %w", err)
+				}
+				lock.Unlock()
+				cancel()
+				return
+			}
+			for i := range indexes {
+				// A file name in the transpile options takes precedence over both the source's name and the
+				// name of its reader
+				var callOpts []TranspileOptionFunc
+				if cfg.FileName != "" {
+					callOpts = append(callOpts, WithFileName(cfg.FileName))
+				} else if inputs[i].Name != "" {
+					callOpts = append(callOpts, WithFileName(inputs[i].Name))
+				}
+				result, err := t.TranspileModuleCtx(ctx, inputs[i].Script, callOpts...)
+				if err != nil && ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				results[i].Result, results[i].Err = result, err
+
+				lock.Lock()
+				completed++
+				if batch.Progress != nil {
+					batch.Progress(BatchProgress{Completed: completed, Total: len(inputs), Index: i, Name: inputs[i].Name, Err: err})
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	next := 0
+feed:
+	for ; next < len(inputs); next++ {
+		select {
+		case indexes <- next:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if createErr != nil {
+		return nil, createErr
+	}
+	for i := next; i < len(inputs); i++ {
+		results[i].Err = ctx.Err()
+	}
+	return results, ctx.Err()
+}
@@ -0,0 +1,105 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranspileBatch(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+	transpileOpts := WithBatchTranspileOptions(WithRegistry(registry), WithVersion("v4.2.3"))
+
+	sources := func(n int) []Source {
+		inputs := make([]Source, n)
+		for i := range inputs {
+			inputs[i] = Source{
+				Name:   fmt.Sprintf("script%d.ts", i),
+				Script: strings.NewReader(fmt.Sprintf("let a: number = %d;", i)),
+			}
+		}
+		return inputs
+	}
+
+	t.Run("results in input order", func(t *testing.T) {
+		var progress []BatchProgress
+		results, err := TranspileBatch(context.Background(), sources(8), transpileOpts,
+			WithBatchConcurrency(3),
+			WithBatchProgress(func(p BatchProgress) {
+				progress = append(progress, p)
+			}))
+		require.NoError(t, err)
+		require.Len(t, results, 8)
+		for i, result := range results {
+			require.NoError(t, result.Err)
+			require.Equal(t, fmt.Sprintf("script%d.ts", i), result.Name)
+			require.Equal(t, fmt.Sprintf("var a = %d;", i), result.Result.Code)
+		}
+		require.Len(t, progress, 8)
+		for i, p := range progress {
+			require.Equal(t, i+1, p.Completed)
+			require.Equal(t, 8, p.Total)
+		}
+	})
+
+	t.Run("file names", func(t *testing.T) {
+		inputs := []Source{
+			{Name: "named.ts", Script: strings.NewReader("let a: number = ;")},
+			{Script: strings.NewReader("let a: number = ;")},
+			{Script: namedReader{strings.NewReader("let a: number = ;"), "reader.ts"}},
+		}
+		results, err := TranspileBatch(context.Background(), inputs, transpileOpts)
+		require.NoError(t, err)
+		for i, expected := range []string{"named.ts", "module.ts", "reader.ts"} {
+			require.Equal(t, expected, results[i].Result.Diagnostics[0].File)
+		}
+
+		// A file name in the transpile options is used for every source
+		for i := range inputs {
+			inputs[i].Script = strings.NewReader("let a: number = ;")
+		}
+		inputs[2].Script = namedReader{strings.NewReader("let a: number = ;"), "reader.ts"}
+		results, err = TranspileBatch(context.Background(), inputs, transpileOpts,
+			WithBatchTranspileOptions(WithFileName("configured.ts")))
+		require.NoError(t, err)
+		for _, result := range results {
+			require.Equal(t, "configured.ts", result.Result.Diagnostics[0].File)
+		}
+	})
+
+	t.Run("per-source errors", func(t *testing.T) {
+		inputs := sources(2)
+		inputs[1].Script = &failingReader{}
+		results, err := TranspileBatch(context.Background(), inputs, transpileOpts)
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		require.Error(t, results[1].Err)
+		require.Contains(t, results[1].Err.Error(), "intentional error")
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		results, err := TranspileBatch(ctx, sources(20), transpileOpts,
+			WithBatchConcurrency(2),
+			WithBatchProgress(func(p BatchProgress) {
+				cancel()
+			}))
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Len(t, results, 20)
+		require.True(t, errors.Is(results[19].Err, context.Canceled))
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		_, err := TranspileBatch(context.Background(), sources(2),
+			WithBatchTranspileOptions(WithRegistry(registry), WithVersion("v0.0.0")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "creating transpiler")
+	})
+}
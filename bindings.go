@@ -0,0 +1,128 @@
+package typescript
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dop251/goja"
+)
+
+// HostModulePrefix is the prefix of the module specifiers of host modules, scripts import the host module
+// "mail" from "host:mail".
+const HostModulePrefix = "host:"
+
+// Bindings is a registry of host modules, each a set of Go functions, constants and objects, that scripts can
+// import. The bindings are made available to evaluated scripts with WithBindings, and their declarations are
+// made available to type checking with WithBindingsDeclarations. Bindings are safe for concurrent use.
+type Bindings struct {
+	lock               sync.RWMutex
+	modules            map[string]*HostModule
+	order              []string
+	declarationOptions []DeclarationOptionFunc
+}
+
+// HostModule is a module of Go values that scripts can import, see Bindings.
+type HostModule struct {
+	bindings *Bindings
+	name     string
+	names    []string
+	values   map[string]interface{}
+}
+
+// NewBindings creates an empty registry of host modules. The declaration options configure how the
+// declarations of the modules are generated, and should use the same goja.FieldNameMapper as the runtimes
+// the scripts are evaluated in.
+func NewBindings(opts ...DeclarationOptionFunc) *Bindings {
+	return &Bindings{modules: make(map[string]*HostModule), declarationOptions: opts}
+}
+
+// Module returns the host module with the provided name, creating it if it doesn't exist. Scripts import the
+// module from HostModulePrefix followed by the name.
+func (b *Bindings) Module(name string) *HostModule {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if m, ok := b.modules[name]; ok {
+		return m
+	}
+	m := &HostModule{bindings: b, name: name, values: make(map[string]interface{})}
+	b.modules[name] = m
+	b.order = append(b.order, name)
+	return m
+}
+
+// Modules returns the names of the host modules in the order they were created.
+func (b *Bindings) Modules() []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return append([]string(nil), b.order...)
+}
+
+// Declarations returns a declaration file that declares each host module and its exports, see
+// DeclarationGenerator for how Go types are declared.
+func (b *Bindings) Declarations() string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	g := NewDeclarationGenerator(b.declarationOptions...)
+	for _, name := range b.order {
+		m := b.modules[name]
+		for _, export := range m.names {
+			g.AddModuleExport(m.Specifier(), export, m.values[export])
+		}
+	}
+	return g.String()
+}
+
+// Name returns the name of the module.
+func (m *HostModule) Name() string {
+	return m.name
+}
+
+// Specifier returns the module specifier that scripts import the module from.
+func (m *HostModule) Specifier() string {
+	return HostModulePrefix + m.name
+}
+
+// Set exports a Go value, such as a function, constant or object, from the module under the provided name,
+// replacing any value previously exported under the name. It returns the module so that calls can be chained.
+func (m *HostModule) Set(name string, value interface{}) *HostModule {
+	m.bindings.lock.Lock()
+	defer m.bindings.lock.Unlock()
+	if _, ok := m.values[name]; !ok {
+		m.names = append(m.names, name)
+	}
+	m.values[name] = value
+	return m
+}
+
+// load creates the exports object of the module in the runtime.
+func (m *HostModule) load(runtime *goja.Runtime) (goja.Value, error) {
+	m.bindings.lock.RLock()
+	defer m.bindings.lock.RUnlock()
+	exports := runtime.NewObject()
+	for _, name := range m.names {
+		err := exports.Set(name, m.values[name])
+		if err != nil {
+			return nil, fmt.Errorf("setting export '%s': %w", name, err)
+		}
+	}
+	return exports, nil
+}
+
+// WithBindings makes the host modules in the bindings available to the evaluated script, see WithModule.
+// Transpiling doesn't type check the script, so the declarations of the modules aren't needed to evaluate it.
+func WithBindings(bindings *Bindings) EvaluateOptionFunc {
+	return func(cfg *EvaluateConfig) {
+		for _, name := range bindings.Modules() {
+			m := bindings.Module(name)
+			WithModule(m.Specifier(), m.load)(cfg)
+		}
+	}
+}
+
+// WithBindingsDeclarations adds the declarations of the host modules in the bindings as bindings.d.ts, so that
+// type checking with Check, Compile and LanguageService knows about the modules.
+func WithBindingsDeclarations(bindings *Bindings) TranspileOptionFunc {
+	return func(config *Config) {
+		WithDeclarations("bindings.d.ts", bindings.Declarations())(config)
+	}
+}
@@ -0,0 +1,99 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/require"
+)
+
+type bindingsMessage struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+func TestBindings(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+
+	var sent []bindingsMessage
+	bindings := NewBindings(WithFieldNameMapper(goja.TagFieldNameMapper("json", true)))
+	bindings.Module("mail").
+		Set("sendEmail", func(m bindingsMessage) error {
+			if m.To == "" {
+				return errors.New("missing recipient")
+			}
+			sent = append(sent, m)
+			return nil
+		}).
+		Set("maxRecipients", 10)
+	bindings.Module("log").Set("info", func(msg string) {})
+	require.Equal(t, []string{"mail", "log"}, bindings.Modules())
+	require.Equal(t, "host:mail", bindings.Module("mail").Specifier())
+
+	require.Equal(t, strings.Join([]string{
+		`declare module "host:mail" {`,
+		`    export function sendEmail(arg0: go.bindingsMessage): void;`,
+		`    export const maxRecipients: number;`,
+		`}`,
+		`declare module "host:log" {`,
+		`    export function info(arg0: string): void;`,
+		`}`,
+		`declare namespace go {`,
+		`    interface bindingsMessage {`,
+		`        to: string;`,
+		`        subject: string;`,
+		`    }`,
+		`}`,
+		``,
+	}, "\n"), bindings.Declarations())
+
+	t.Run("evaluate", func(t *testing.T) {
+		runtime := goja.New()
+		runtime.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+		script := strings.Join([]string{
+			`import { sendEmail, maxRecipients } from "host:mail";`,
+			`sendEmail({ to: "a@example.com", subject: "hi" });`,
+			`maxRecipients;`,
+		}, "\n")
+		result, err := Evaluate(strings.NewReader(script),
+			WithEvaluationRuntime(runtime),
+			WithTranspile(),
+			WithTranspileOptions(WithRegistry(registry), WithVersion("v4.9.3")),
+			WithBindings(bindings))
+		require.NoError(t, err)
+		require.Equal(t, int64(10), result.ToInteger())
+		require.Equal(t, []bindingsMessage{{To: "a@example.com", Subject: "hi"}}, sent)
+
+		_, err = Evaluate(strings.NewReader(`import { sendEmail } from "host:mail"; sendEmail({ subject: "hi" });`),
+			WithTranspile(),
+			WithTranspileOptions(WithRegistry(registry), WithVersion("v4.9.3")),
+			WithBindings(bindings))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "missing recipient")
+	})
+
+	t.Run("check", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"node_modules/typescript/lib/lib.d.ts": {Data: []byte(minimalLib)},
+			"index.ts": {Data: []byte(strings.Join([]string{
+				`import { sendEmail } from "host:mail";`,
+				`import { info } from "host:log";`,
+				`sendEmail({ to: "a@example.com", subject: "hi" });`,
+				`info(10);`,
+			}, "\n"))},
+		}
+		result, err := Check(context.Background(), fsys, []string{"index.ts"},
+			WithRegistry(registry), WithVersion("v4.9.3"), WithBindingsDeclarations(bindings))
+		require.NoError(t, err)
+		require.Len(t, result.Diagnostics, 1)
+		require.Equal(t, 2345, result.Diagnostics[0].Code)
+		require.Equal(t, 4, result.Diagnostics[0].Line)
+	})
+}
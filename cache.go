@@ -0,0 +1,82 @@
+package typescript
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// TranspileCache stores transpile results so that the same script doesn't need to be transpiled more than
+// once. Results are keyed by a hash of the script, the compile options, the file name, the module name, the
+// typescript version and any custom transformers, and are stored as opaque bytes so that any key-value store
+// can be used. Implementations must be safe for concurrent use.
+type TranspileCache interface {
+	// Get returns the value stored for key, and false if there is no value for key.
+	Get(key string) ([]byte, bool, error)
+	// Set stores the value for key.
+	Set(key string, value []byte) error
+}
+
+// cacheKey returns the key for the script transpiled with the provided config. The key is a hex encoded
+// SHA-256 hash of every input that affects the transpiled output.
+func cacheKey(cfg *Config, script []byte) (string, error) {
+	scriptHash := sha256.Sum256(script)
+	// The typed compiler options are validated when transpiling, they are part of the key separately from the
+	// merged options so that a result is only reused for typed options that passed validation
+	var typedOptions map[string]interface{}
+	if cfg.CompilerOptions != nil {
+		typedOptions = cfg.CompilerOptions.toMap()
+	}
+	// Maps are marshalled with sorted keys, so equivalent compile options always produce the same bytes
+	b, err := json.Marshal(struct {
+		Script          string                 `json:"script"`
+		CompileOptions  map[string]interface{} `json:"compileOptions"`
+		CompilerOptions map[string]interface{} `json:"compilerOptions,omitempty"`
+		FileName        string                 `json:"fileName"`
+		ModuleName      string                 `json:"moduleName"`
+		Version         string                 `json:"version"`
+		Transformers    Transformers           `json:"transformers"`
+	}{
+		Script:          hex.EncodeToString(scriptHash[:]),
+		CompileOptions:  cfg.compilerOptions(),
+		CompilerOptions: typedOptions,
+		FileName:        cfg.FileName,
+		ModuleName:      cfg.ModuleName,
+		Version:         cfg.TypescriptVersion,
+		Transformers:    cfg.Transformers,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshalling cache key: %w", err)
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
+
+func getCachedResult(cache TranspileCache, key string) (*TranspileResult, bool, error) {
+	b, ok, err := cache.Get(key)
+	if err != nil {
+		return nil, false, fmt.Errorf("reading from transpile cache: %w", err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	var result TranspileResult
+	err = json.Unmarshal(b, &result)
+	if err != nil {
+		return nil, false, fmt.Errorf("decoding cached transpile result: %w", err)
+	}
+	return &result, true, nil
+}
+
+func setCachedResult(cache TranspileCache, key string, result *TranspileResult) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("encoding transpile result: %w", err)
+	}
+	err = cache.Set(key, b)
+	if err != nil {
+		return fmt.Errorf("writing to transpile cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package typescript
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var _ TranspileCache = &DirCache{}
+
+// DirCache is a TranspileCache that stores each entry as a file in a directory, so that it can be shared
+// between processes. Entries are sharded into sub-directories by the first two characters of their key.
+type DirCache struct {
+	dir string
+}
+
+// NewDirCache creates a cache that stores entries in dir, creating the directory if it doesn't exist.
+func NewDirCache(dir string) (*DirCache, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	return &DirCache{dir: dir}, nil
+}
+
+func (c *DirCache) Get(key string) ([]byte, bool, error) {
+	b, err := ioutil.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// Set writes the entry to a temporary file and renames it so that concurrent readers, including other
+// processes, never observe a partially written entry.
+func (c *DirCache) Set(key string, value []byte) error {
+	name := c.path(key)
+	err := os.MkdirAll(filepath.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+func (c *DirCache) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, key)
+	}
+	return filepath.Join(c.dir, key[:2], key)
+}
@@ -0,0 +1,68 @@
+package typescript
+
+import (
+	"container/list"
+	"sync"
+)
+
+var _ TranspileCache = &MemoryCache{}
+
+// MemoryCache is an in-memory TranspileCache that evicts the least recently used entry once it holds
+// the maximum number of entries.
+type MemoryCache struct {
+	lock       sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	// order holds the entries from most to least recently used
+	order *list.List
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache creates an in-memory cache that holds at most maxEntries results. If maxEntries is zero
+// or less, the cache is unbounded.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*memoryCacheEntry).value, true, nil
+}
+
+func (c *MemoryCache) Set(key string, value []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*memoryCacheEntry).value = value
+		c.order.MoveToFront(e)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry{key: key, value: value})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache.
+func (c *MemoryCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
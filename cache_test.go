@@ -0,0 +1,118 @@
+package typescript
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache(t *testing.T) {
+	c := NewMemoryCache(2)
+	require.NoError(t, c.Set("a", []byte("1")))
+	require.NoError(t, c.Set("b", []byte("2")))
+	_, ok, _ := c.Get("a")
+	require.True(t, ok)
+	// 'b' is now the least recently used entry
+	require.NoError(t, c.Set("c", []byte("3")))
+	require.Equal(t, 2, c.Len())
+	_, ok, _ = c.Get("b")
+	require.False(t, ok)
+	v, ok, err := c.Get("a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "1", string(v))
+}
+
+func TestDirCache(t *testing.T) {
+	c, err := NewDirCache(t.TempDir())
+	require.NoError(t, err)
+	_, ok, err := c.Get("abcdef")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, c.Set("abcdef", []byte("value")))
+	require.NoError(t, c.Set("abcdef", []byte("updated")))
+	v, ok, err := c.Get("abcdef")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "updated", string(v))
+}
+
+func TestTranspileCache(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+	// Transpiling with an empty registry fails, so a successful transpile proves the cache was used
+	empty := versions.NewRegistry()
+
+	dir, err := NewDirCache(t.TempDir())
+	require.NoError(t, err)
+	for name, cache := range map[string]TranspileCache{"memory": NewMemoryCache(10), "dir": dir} {
+		t.Run(name, func(t *testing.T) {
+			result, err := TranspileModuleString("let a: number = ;", WithCache(cache), WithSourceMap(),
+				WithRegistry(registry), WithVersion("v4.2.3"))
+			require.NoError(t, err)
+
+			cached, err := TranspileModuleString("let a: number = ;", WithCache(cache), WithSourceMap(),
+				WithRegistry(empty), WithVersion("v4.2.3"))
+			require.NoError(t, err)
+			require.Equal(t, result.Code, cached.Code)
+			require.Equal(t, result.Diagnostics, cached.Diagnostics)
+			require.Equal(t, result.SourceMap.Mappings, cached.SourceMap.Mappings)
+
+			// Diagnostic errors are still enforced for cached results
+			_, err = TranspileModuleString("let a: number = ;", WithCache(cache), WithSourceMap(),
+				WithRegistry(empty), WithVersion("v4.2.3"), WithFailOnDiagnosticErrors())
+			var diagnosticsErr *DiagnosticsError
+			require.True(t, errors.As(err, &diagnosticsErr))
+
+			// Any change to the inputs is a cache miss
+			_, err = TranspileModuleString("let a: number = ;", WithCache(cache),
+				WithRegistry(empty), WithVersion("v4.2.3"))
+			require.Error(t, err)
+			_, err = TranspileModuleString("let a: number = ;", WithCache(cache), WithSourceMap(), WithModuleName("other"),
+				WithRegistry(empty), WithVersion("v4.2.3"))
+			require.Error(t, err)
+			_, err = TranspileModuleString("let a: number = ;", WithCache(cache), WithSourceMap(),
+				WithTransformers(Transformers{After: []string{"function () { return function (f) { return f; }; }"}}),
+				WithRegistry(empty), WithVersion("v4.2.3"))
+			require.Error(t, err)
+			// Typed compiler options are validated even if the same options were cached as compile options
+			_, err = TranspileModuleString("let a = 10;", WithCache(cache),
+				WithCompileOptions(map[string]interface{}{"target": "es2099"}),
+				WithRegistry(registry), WithVersion("v4.2.3"))
+			require.NoError(t, err)
+			_, err = TranspileModuleString("let a = 10;", WithCache(cache),
+				WithCompilerOptions(CompilerOptions{Other: map[string]interface{}{"target": "es2099"}}),
+				WithRegistry(registry), WithVersion("v4.2.3"))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid compiler options")
+			// Nor are results cached when there are visitors
+			_, err = TranspileModuleString("let a: number = ;", WithCache(cache), WithSourceMap(),
+				WithVisitor(func(*Node) NodeAction { return KeepNode }),
+				WithRegistry(empty), WithVersion("v4.2.3"))
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("transpiler", func(t *testing.T) {
+		cache := NewMemoryCache(10)
+		transpiler, err := NewTranspiler(WithRegistry(registry), WithVersion("v4.2.3"), WithCache(cache))
+		require.NoError(t, err)
+		_, err = transpiler.TranspileString("let a: number = 10;")
+		require.NoError(t, err)
+		require.Equal(t, 1, cache.Len())
+	})
+
+	t.Run("evaluate", func(t *testing.T) {
+		cache := NewMemoryCache(10)
+		for _, r := range []versions.Registry{registry, empty} {
+			result, err := Evaluate(strings.NewReader("let a: number = 10; a"), WithTranspile(),
+				WithTranspileOptions(WithCache(cache), WithRegistry(r), WithVersion("v4.2.3")))
+			require.NoError(t, err)
+			require.Equal(t, int64(10), result.ToInteger())
+		}
+	})
+}
@@ -0,0 +1,149 @@
+// Command tsls is a Language Server Protocol server for typescript that runs the compiler embedded in
+// go-typescript, so editors get the same diagnostics as the runtime without needing Node. It communicates
+// over stdio and supports diagnostics, completions, hover information and go to definition.
+//
+// Usage:
+//
+//	tsls [-version v4.9.3] [-lib dir] [-tsconfig tsconfig.json] [-declarations path]...
+//
+// Host provided ambient declarations are loaded from the .d.ts files given with -declarations, which may be
+// repeated and may name directories. The default lib files (lib.d.ts, lib.es2015.d.ts, etc.) are read from
+// the -lib directory, such as node_modules/typescript/lib of an npm installation of the same version.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clarkmcc/go-typescript"
+	"github.com/clarkmcc/go-typescript/versions"
+	_ "github.com/clarkmcc/go-typescript/versions/all"
+)
+
+// pathsFlag is a flag that can be repeated to provide multiple paths.
+type pathsFlag []string
+
+func (p *pathsFlag) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathsFlag) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+func main() {
+	var declarations pathsFlag
+	version := flag.String("version", "v4.9.3", "the typescript version used by the language service")
+	libDir := flag.String("lib", "", "the directory containing the default lib.*.d.ts files")
+	npmPackage := flag.String("typescript", "", "a typescript npm package directory or .tgz archive to load the compiler and its lib files from, in place of -version")
+	tsconfig := flag.String("tsconfig", "", "a tsconfig.json file to read the compiler options from")
+	flag.Var(&declarations, "declarations", "a .d.ts file, or a directory of .d.ts files, with ambient declarations (may be repeated)")
+	flag.Parse()
+
+	// Logs are written to stderr since stdout is used for the protocol
+	log.SetOutput(os.Stderr)
+	log.SetPrefix("tsls: ")
+
+	opts, err := options(*version, *libDir, *npmPackage, *tsconfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	service, err := typescript.NewLanguageService(opts...)
+	if err != nil {
+		log.Fatalf("creating language service: %v", err)
+	}
+	var declarationFiles []string
+	for _, path := range declarations {
+		names, err := loadDeclarations(service, path)
+		if err != nil {
+			log.Fatalf("loading declarations: %v", err)
+		}
+		declarationFiles = append(declarationFiles, names...)
+	}
+	libs := ""
+	if *libDir != "" {
+		libs, err = filepath.Abs(*libDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	err = newServer(service, os.Stdin, os.Stdout, libs, declarationFiles).serve()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// options returns the options used to create the language service. The bundled versions are used from
+// versions.DefaultRegistry. If npmPackage is set, the compiler is loaded from the typescript npm package
+// directory or .tgz archive and is used in place of the version.
+func options(version, libDir, npmPackage, tsconfig string) ([]typescript.TranspileOptionFunc, error) {
+	registry := versions.DefaultRegistry
+	if npmPackage != "" {
+		var err error
+		if strings.HasSuffix(npmPackage, ".tgz") {
+			version, err = versions.RegisterNpmArchive(registry, npmPackage)
+		} else {
+			version, err = versions.RegisterNpmPackage(registry, os.DirFS(npmPackage))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("loading typescript package: %w", err)
+		}
+	}
+	if libDir != "" {
+		// The libs are looked up by the tag the version resolves to, which differs from a constraint such as ^4.9
+		tag, err := registry.Resolve(version)
+		if err != nil {
+			return nil, fmt.Errorf("resolving version: %w", err)
+		}
+		registry.RegisterLibs(tag, os.DirFS(libDir))
+	}
+	opts := []typescript.TranspileOptionFunc{typescript.WithRegistry(registry), typescript.WithVersion(version)}
+	if tsconfig != "" {
+		// The config is loaded from the root of the file system so that the paths in it are the absolute
+		// paths the server uses for open documents
+		file, err := filepath.Abs(tsconfig)
+		if err != nil {
+			return nil, fmt.Errorf("loading tsconfig: %w", err)
+		}
+		file = filepath.ToSlash(strings.TrimPrefix(file, filepath.VolumeName(file)))
+		config, err := typescript.LoadTSConfig(os.DirFS("/"), strings.TrimPrefix(file, "/"), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("loading tsconfig: %w", err)
+		}
+		opts = append(opts, typescript.WithTSConfig(config))
+	}
+	return opts, nil
+}
+
+// loadDeclarations adds the .d.ts file at path, or all of the .d.ts files in the directory at path, to the
+// language service and returns the names of the files that were added.
+func loadDeclarations(service *typescript.LanguageService, path string) ([]string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(name, ".d.ts") {
+			return nil
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		service.SetFile(name, string(b))
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
@@ -0,0 +1,576 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/clarkmcc/go-typescript"
+)
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// errExit is returned by a handler to stop the server.
+var errExit = errors.New("exit")
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   responseError   `json:"error"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+type didOpenParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type diagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     int      `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+type completionItem struct {
+	Label      string `json:"label"`
+	Kind       int    `json:"kind"`
+	SortText   string `json:"sortText"`
+	InsertText string `json:"insertText,omitempty"`
+}
+
+type completionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []completionItem `json:"items"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    lspRange      `json:"range"`
+}
+
+// server is a language server that serves a single client over a reader and writer. Requests are handled
+// one at a time in the order they are received.
+type server struct {
+	service *typescript.LanguageService
+	reader  *bufio.Reader
+	writer  io.Writer
+	// libDir is the directory on disk that the default lib files were read from, used to map definitions in
+	// lib files to files the editor can open.
+	libDir string
+	// declarations maps the ambient declaration files in the language service to the files on disk they were
+	// loaded from. They remain in the language service when they are closed in the editor.
+	declarations map[string]string
+	// open is the set of files open in the editor.
+	open     map[string]bool
+	shutdown bool
+}
+
+func newServer(service *typescript.LanguageService, r io.Reader, w io.Writer, libDir string, declarations []string) *server {
+	s := &server{
+		service:      service,
+		reader:       bufio.NewReader(r),
+		writer:       w,
+		libDir:       libDir,
+		declarations: make(map[string]string),
+		open:         make(map[string]bool),
+	}
+	for _, name := range declarations {
+		s.declarations[path.Clean("/"+name)] = name
+	}
+	return s
+}
+
+// serve handles messages until the client sends an exit notification or closes the connection. As required
+// by the protocol, an error is returned if the client exits without first requesting a shutdown.
+func (s *server) serve() error {
+	for {
+		b, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+		var msg message
+		err = json.Unmarshal(b, &msg)
+		if err != nil {
+			err = s.write(errorResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: responseError{Code: codeParseError, Message: err.Error()}})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err = s.handle(msg)
+		if err == errExit {
+			if !s.shutdown {
+				return errors.New("received exit notification before shutdown request")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// read reads the content of a single message, framed with a Content-Length header.
+func (s *server) read() ([]byte, error) {
+	header, err := textproto.NewReader(s.reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+	b := make([]byte, length)
+	_, err = io.ReadFull(s.reader, b)
+	return b, err
+}
+
+// write writes a single message, framed with a Content-Length header.
+func (s *server) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshalling message: %w", err)
+	}
+	_, err = fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	return err
+}
+
+// handle handles a request or notification, writing the response to requests.
+func (s *server) handle(msg message) error {
+	isRequest := len(msg.ID) > 0
+	result, err := s.dispatch(msg)
+	if err == errExit {
+		return err
+	}
+	if !isRequest {
+		if err != nil && !errors.Is(err, errMethodNotFound) {
+			log.Printf("handling %s: %v", msg.Method, err)
+		}
+		return nil
+	}
+	if err != nil {
+		code := codeInternalError
+		var paramsErr *json.UnmarshalTypeError
+		if errors.Is(err, errMethodNotFound) {
+			code = codeMethodNotFound
+		} else if errors.As(err, &paramsErr) {
+			code = codeInvalidParams
+		}
+		return s.write(errorResponse{JSONRPC: "2.0", ID: msg.ID, Error: responseError{Code: code, Message: err.Error()}})
+	}
+	return s.write(response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+var errMethodNotFound = errors.New("method not found")
+
+func (s *server) dispatch(msg message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// Full document sync
+				"textDocumentSync":   1,
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{"."}},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]interface{}{"name": "tsls", "version": s.service.Version()},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "exit":
+		return nil, errExit
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.setFile(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.setFile(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.closeFile(params.TextDocument.URI)
+	case "textDocument/completion":
+		return s.positionRequest(msg, s.completion)
+	case "textDocument/hover":
+		return s.positionRequest(msg, s.hover)
+	case "textDocument/definition":
+		return s.positionRequest(msg, s.definition)
+	}
+	return nil, fmt.Errorf("%w: %s", errMethodNotFound, msg.Method)
+}
+
+// setFile updates the text of an open file and publishes the diagnostics of every open file, since a change
+// in one file can affect the others.
+func (s *server) setFile(uri, text string) error {
+	name, err := uriToPath(uri)
+	if err != nil {
+		return err
+	}
+	s.open[name] = true
+	s.service.SetFile(name, text)
+	return s.publishDiagnostics()
+}
+
+func (s *server) closeFile(uri string) error {
+	name, err := uriToPath(uri)
+	if err != nil {
+		return err
+	}
+	delete(s.open, name)
+	if file, ok := s.declarations[name]; ok {
+		// Discard any unsaved changes made in the editor
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		s.service.SetFile(name, string(b))
+	} else {
+		s.service.RemoveFile(name)
+	}
+	err = s.write(notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: []diagnostic{},
+	}})
+	if err != nil {
+		return err
+	}
+	return s.publishDiagnostics()
+}
+
+func (s *server) publishDiagnostics() error {
+	for _, name := range s.service.Files() {
+		if !s.open[name] {
+			continue
+		}
+		text, _ := s.service.File(name)
+		diagnostics, err := s.service.Diagnostics(name)
+		if err != nil {
+			return err
+		}
+		params := publishDiagnosticsParams{URI: s.pathToURI(name), Diagnostics: []diagnostic{}}
+		for _, d := range diagnostics {
+			// Diagnostics that aren't in a file, such as those for the compile options, have no position and
+			// are reported at the start of the file
+			r := lspRange{}
+			if d.Line > 0 {
+				r = lspRange{Start: linePosition(d.Line, d.Column), End: offsetToPosition(text, d.Start+d.Length)}
+			}
+			params.Diagnostics = append(params.Diagnostics, diagnostic{
+				Range:    r,
+				Severity: severity(d.Category),
+				Code:     d.Code,
+				Source:   "typescript",
+				Message:  d.Message,
+			})
+		}
+		err = s.write(notification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: params})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// positionRequest decodes the params of a request for a position in a document and calls fn with the
+// document's file name, text and the position as an offset.
+func (s *server) positionRequest(msg message, fn func(name, text string, offset int) (interface{}, error)) (interface{}, error) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return nil, err
+	}
+	name, err := uriToPath(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	text, ok := s.service.File(name)
+	if !ok {
+		return nil, fmt.Errorf("document '%s' is not open", params.TextDocument.URI)
+	}
+	return fn(name, text, positionToOffset(text, params.Position))
+}
+
+func (s *server) completion(name, _ string, offset int) (interface{}, error) {
+	info, err := s.service.Completions(name, offset)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	list := completionList{Items: make([]completionItem, 0, len(info.Entries))}
+	for _, e := range info.Entries {
+		list.Items = append(list.Items, completionItem{
+			Label:      e.Name,
+			Kind:       completionKind(e.Kind),
+			SortText:   e.SortText,
+			InsertText: e.InsertText,
+		})
+	}
+	return list, nil
+}
+
+func (s *server) hover(name, text string, offset int) (interface{}, error) {
+	info, err := s.service.QuickInfo(name, offset)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	value := "```typescript\n" + info.Display + "\n```"
+	if info.Documentation != "" {
+		value += "\n\n" + info.Documentation
+	}
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: value},
+		Range: lspRange{
+			Start: offsetToPosition(text, info.TextSpan.Start),
+			End:   offsetToPosition(text, info.TextSpan.Start+info.TextSpan.Length),
+		},
+	}, nil
+}
+
+func (s *server) definition(name, _ string, offset int) (interface{}, error) {
+	definitions, err := s.service.Definition(name, offset)
+	if err != nil {
+		return nil, err
+	}
+	locations := make([]location, 0, len(definitions))
+	for _, d := range definitions {
+		locations = append(locations, location{
+			URI: s.pathToURI(d.FileName),
+			Range: lspRange{
+				Start: linePosition(d.Line, d.Column),
+				End:   linePosition(d.EndLine, d.EndColumn),
+			},
+		})
+	}
+	return locations, nil
+}
+
+// uriToPath converts a file URI into the file name used by the language service.
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("parsing document uri: %w", err)
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported document uri '%s', only file uris are supported", uri)
+	}
+	return path.Clean("/" + u.Path), nil
+}
+
+// pathToURI converts a file name used by the language service into a file URI. Default lib files are mapped
+// to the lib directory on disk, if there is one.
+func (s *server) pathToURI(name string) string {
+	if s.libDir != "" && path.Dir(name) == typescript.DefaultLibLocation {
+		name = path.Join("/", strings.TrimPrefix(strings.ReplaceAll(s.libDir, "\\", "/"), "/"), path.Base(name))
+	}
+	return (&url.URL{Scheme: "file", Path: name}).String()
+}
+
+// linePosition converts a 1-based line and column into a position. Missing lines and columns, which are 0, are
+// clamped to the start of the file or line.
+func linePosition(line, column int) position {
+	if line < 1 {
+		return position{}
+	}
+	if column < 1 {
+		return position{Line: line - 1}
+	}
+	return position{Line: line - 1, Character: column - 1}
+}
+
+// positionToOffset converts a line and UTF-16 character position into a UTF-16 offset in text. Positions past
+// the end of a line are clamped to the end of the line.
+func positionToOffset(text string, pos position) int {
+	offset, line, character := 0, 0, 0
+	for _, r := range text {
+		if line == pos.Line && (character >= pos.Character || r == '\n') {
+			return offset
+		}
+		if r == '\n' {
+			line++
+		} else if line == pos.Line {
+			character += utf16Len(r)
+		}
+		offset += utf16Len(r)
+	}
+	return offset
+}
+
+// offsetToPosition converts a UTF-16 offset in text into a line and UTF-16 character position.
+func offsetToPosition(text string, offset int) position {
+	var pos position
+	units := 0
+	for _, r := range text {
+		if units >= offset {
+			break
+		}
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character += utf16Len(r)
+		}
+		units += utf16Len(r)
+	}
+	return pos
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// severity converts a diagnostic category into an LSP diagnostic severity.
+func severity(category typescript.DiagnosticCategory) int {
+	switch category {
+	case typescript.DiagnosticCategoryError:
+		return 1
+	case typescript.DiagnosticCategoryWarning:
+		return 2
+	case typescript.DiagnosticCategoryMessage:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// completionKinds maps the kinds of typescript symbols to LSP completion item kinds.
+var completionKinds = map[string]int{
+	"primitive type":       14,
+	"keyword":              14,
+	"const":                21,
+	"let":                  6,
+	"var":                  6,
+	"local var":            6,
+	"alias":                6,
+	"parameter":            6,
+	"property":             5,
+	"getter":               5,
+	"setter":               5,
+	"function":             3,
+	"local function":       3,
+	"method":               2,
+	"construct":            2,
+	"call":                 2,
+	"index":                2,
+	"enum":                 13,
+	"enum member":          20,
+	"module":               9,
+	"external module name": 9,
+	"class":                7,
+	"type":                 7,
+	"interface":            8,
+	"string":               21,
+}
+
+func completionKind(kind string) int {
+	if k, ok := completionKinds[kind]; ok {
+		return k
+	}
+	// Text
+	return 1
+}
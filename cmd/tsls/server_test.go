@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript"
+	"github.com/stretchr/testify/require"
+)
+
+// lib declares the global types the compiler requires to exist, it stands in for lib.d.ts
+const lib = `
+interface Array<T> { length: number; [n: number]: T; }
+interface Boolean {}
+interface CallableFunction {}
+interface Function {}
+interface IArguments {}
+interface NewableFunction {}
+interface Number { toFixed(digits?: number): string; }
+interface Object {}
+interface RegExp {}
+interface String {}
+`
+
+func frame(t *testing.T, messages ...interface{}) *bytes.Buffer {
+	var buf bytes.Buffer
+	for _, m := range messages {
+		b, err := json.Marshal(m)
+		require.NoError(t, err)
+		_, _ = fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	}
+	return &buf
+}
+
+func request(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func TestServer(t *testing.T) {
+	dir, libDir := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "lib.d.ts"), []byte(lib), 0644))
+	declarations := filepath.Join(dir, "host.d.ts")
+	require.NoError(t, os.WriteFile(declarations, []byte("declare function getUser(): { name: string; age: number };"), 0644))
+
+	opts, err := options("v4.9.3", libDir, "", "")
+	require.NoError(t, err)
+	service, err := typescript.NewLanguageService(opts...)
+	require.NoError(t, err)
+	names, err := loadDeclarations(service, dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.ToSlash(declarations)}, names)
+
+	uri := "file:///project/index.ts"
+	text := "const user = getUser();\nconst n: string = user.age;\nuser."
+	position := func(line, character int) map[string]interface{} {
+		return map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"position":     map[string]interface{}{"line": line, "character": character},
+		}
+	}
+	input := frame(t,
+		request(1, "initialize", map[string]interface{}{}),
+		notify("initialized", map[string]interface{}{}),
+		notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "typescript", "version": 1, "text": text},
+		}),
+		request(2, "textDocument/hover", position(0, 15)),
+		request(3, "textDocument/completion", position(2, 5)),
+		request(4, "textDocument/definition", position(0, 15)),
+		request(5, "textDocument/unknown", position(0, 0)),
+		request(6, "shutdown", nil),
+		notify("exit", nil),
+	)
+	var output bytes.Buffer
+	require.NoError(t, newServer(service, input, &output, libDir, names).serve())
+
+	responses := map[float64]map[string]interface{}{}
+	var diagnostics []interface{}
+	out := newServer(service, &output, nil, "", nil)
+	for {
+		b, err := out.read()
+		if err != nil {
+			break
+		}
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal(b, &m))
+		if id, ok := m["id"].(float64); ok {
+			responses[id] = m
+		} else if m["method"] == "textDocument/publishDiagnostics" {
+			diagnostics = m["params"].(map[string]interface{})["diagnostics"].([]interface{})
+		}
+	}
+
+	capabilities := responses[1]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	require.Equal(t, true, capabilities["hoverProvider"])
+
+	// The incomplete member access and the type error are both reported
+	require.Len(t, diagnostics, 2)
+	d := diagnostics[1].(map[string]interface{})
+	require.Equal(t, float64(1003), diagnostics[0].(map[string]interface{})["code"])
+	require.Equal(t, float64(2322), d["code"])
+	require.Equal(t, map[string]interface{}{
+		"start": map[string]interface{}{"line": float64(1), "character": float64(6)},
+		"end":   map[string]interface{}{"line": float64(1), "character": float64(7)},
+	}, d["range"])
+
+	hover := responses[2]["result"].(map[string]interface{})
+	require.Contains(t, hover["contents"].(map[string]interface{})["value"], "function getUser(): {")
+
+	var labels []string
+	for _, item := range responses[3]["result"].(map[string]interface{})["items"].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+	require.ElementsMatch(t, []string{"age", "name"}, labels)
+
+	definitions := responses[4]["result"].([]interface{})
+	require.Len(t, definitions, 1)
+	require.Equal(t, "file://"+filepath.ToSlash(declarations), definitions[0].(map[string]interface{})["uri"])
+
+	require.Equal(t, float64(codeMethodNotFound), responses[5]["error"].(map[string]interface{})["code"])
+	require.Contains(t, responses, float64(6))
+}
+
+func TestServerFraming(t *testing.T) {
+	s := newServer(nil, bufio.NewReader(strings.NewReader("Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}")), nil, "", nil)
+	b, err := s.read()
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(b))
+}
+
+func TestPositions(t *testing.T) {
+	text := "a😀b\ncd"
+	require.Equal(t, 3, positionToOffset(text, position{Line: 0, Character: 3}))
+	require.Equal(t, 5, positionToOffset(text, position{Line: 1, Character: 0}))
+	require.Equal(t, 4, positionToOffset(text, position{Line: 0, Character: 10}))
+	require.Equal(t, position{Line: 0, Character: 3}, offsetToPosition(text, 3))
+	require.Equal(t, position{Line: 1, Character: 1}, offsetToPosition(text, 6))
+	require.Equal(t, position{Line: 1, Character: 2}, linePosition(2, 3))
+	require.Equal(t, position{}, linePosition(0, 0))
+	require.Equal(t, position{Line: 1}, linePosition(2, 0))
+}
+
+func TestOptionsConstraint(t *testing.T) {
+	libDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(libDir, "lib.d.ts"), []byte(lib), 0644))
+
+	opts, err := options("^4.9", libDir, "", "")
+	require.NoError(t, err)
+	service, err := typescript.NewLanguageService(opts...)
+	require.NoError(t, err)
+	require.Equal(t, "v4.9.3", service.Version())
+	service.SetFile("/index.ts", "let a: string = (1).toFixed(2);")
+	diagnostics, err := service.Diagnostics("/index.ts")
+	require.NoError(t, err)
+	require.Empty(t, diagnostics)
+
+	_, err = options("^9", libDir, "", "")
+	require.Error(t, err)
+}
+
+func TestOptionsTSConfig(t *testing.T) {
+	dir := t.TempDir()
+	tsconfig := filepath.Join(dir, "tsconfig.json")
+	require.NoError(t, os.WriteFile(tsconfig, []byte(`{"compilerOptions": {"rootDir": "src"}}`), 0644))
+
+	opts, err := options("v4.9.3", "", "", tsconfig)
+	require.NoError(t, err)
+	config := &typescript.Config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	name, err := uriToPath("file://" + filepath.ToSlash(filepath.Join(dir, "src")))
+	require.NoError(t, err)
+	require.Equal(t, name, config.CompileOptions["rootDir"])
+}
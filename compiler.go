@@ -0,0 +1,103 @@
+package typescript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+
+	"github.com/dop251/goja"
+)
+
+// CompileResult is the result of type-checking, and optionally emitting, a typescript program.
+type CompileResult struct {
+	// Diagnostics are the option, syntactic and semantic diagnostics reported for the program.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// Files maps the absolute path of each emitted file to its contents. This is only populated by Compile.
+	Files map[string]string `json:"files"`
+}
+
+// Check type-checks the program made up of rootNames and returns the diagnostics reported by the compiler.
+// The compiler reads all source files from fsys, with the root of fsys being the root directory "/" from the
+// compiler's point of view. The default lib files are resolved from node_modules/typescript/lib in fsys, falling
+// back to the lib files registered for the typescript version if the registry is a versions.LibRegistry. Use the
+// "noLib" compile option if neither are available. Any declarations added with WithDeclarations are also root
+// files of the program.
+func Check(ctx context.Context, fsys fs.FS, rootNames []string, opts ...TranspileOptionFunc) (*CompileResult, error) {
+	return compileProgram(ctx, fsys, rootNames, false, opts)
+}
+
+// Compile type-checks the program made up of rootNames the same way as Check, and also returns the
+// files emitted by the compiler.
+func Compile(ctx context.Context, fsys fs.FS, rootNames []string, opts ...TranspileOptionFunc) (*CompileResult, error) {
+	return compileProgram(ctx, fsys, rootNames, true, opts)
+}
+
+func compileProgram(ctx context.Context, fsys fs.FS, rootNames []string, emit bool, opts []TranspileOptionFunc) (*CompileResult, error) {
+	cfg, done, err := newCompilerConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	err = checkCompilerOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	optionBytes, err := json.Marshal(cfg.compilerOptions())
+	if err != nil {
+		return nil, fmt.Errorf("marshalling compile options: %w", err)
+	}
+	paths := make([]string, len(rootNames), len(rootNames)+len(cfg.Declarations))
+	for i, name := range rootNames {
+		paths[i] = compilerPath(name)
+	}
+	for name := range cfg.Declarations {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths[len(rootNames):])
+	rootNameBytes, err := json.Marshal(paths)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling root names: %w", err)
+	}
+	s := fmt.Sprintf(`(function (host) {
+	var diagnostics = [];
+	var options = ts.fixupCompilerOptions(%s || {}, diagnostics);
+	if (options.lib) {
+		options.lib = options.lib.map(function (lib) { return ts.libMap.get(lib.toLowerCase()) || lib; });
+	}
+	var outputs = {};
+	var program = ts.createProgram(%s, options, (%s)(host, outputs));
+	diagnostics = diagnostics.concat(ts.getPreEmitDiagnostics(program));
+	if (%t) {
+		diagnostics = diagnostics.concat(program.emit().diagnostics);
+	}
+	return JSON.stringify({ diagnostics: (%s)(ts.sortAndDeduplicateDiagnostics(diagnostics)), files: outputs });
+})`, optionBytes, rootNameBytes, compilerHost, emit, diagnosticsConverter)
+	if cfg.Verbose {
+		log.Println(s)
+	}
+	value, err := cfg.Runtime.RunString(s)
+	if err != nil {
+		return nil, fmt.Errorf("creating compiler program: %w", err)
+	}
+	fn, ok := goja.AssertFunction(value)
+	if !ok {
+		return nil, fmt.Errorf("compiler program is not a function")
+	}
+	host := newFSHost(cfg, fsys)
+	value, err = fn(goja.Undefined(), host.object())
+	if err != nil {
+		return nil, fmt.Errorf("running compiler: %w", err)
+	}
+	var result CompileResult
+	err = json.Unmarshal([]byte(value.String()), &result)
+	if err != nil {
+		return nil, fmt.Errorf("decoding compiler output: %w", err)
+	}
+	if cfg.FailOnDiagnosticErrors && hasErrors(result.Diagnostics) {
+		return nil, &DiagnosticsError{Diagnostics: result.Diagnostics}
+	}
+	return &result, nil
+}
@@ -0,0 +1,218 @@
+package typescript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ScriptTarget is the ECMAScript version targeted by the compiler.
+type ScriptTarget string
+
+const (
+	TargetES3    ScriptTarget = "es3"
+	TargetES5    ScriptTarget = "es5"
+	TargetES2015 ScriptTarget = "es2015"
+	TargetES2016 ScriptTarget = "es2016"
+	TargetES2017 ScriptTarget = "es2017"
+	TargetES2018 ScriptTarget = "es2018"
+	TargetES2019 ScriptTarget = "es2019"
+	TargetES2020 ScriptTarget = "es2020"
+	TargetES2021 ScriptTarget = "es2021"
+	TargetES2022 ScriptTarget = "es2022"
+	TargetESNext ScriptTarget = "esnext"
+)
+
+// ModuleKind is the module system of the emitted code.
+type ModuleKind string
+
+const (
+	ModuleNone     ModuleKind = "none"
+	ModuleCommonJS ModuleKind = "commonjs"
+	ModuleAMD      ModuleKind = "amd"
+	ModuleUMD      ModuleKind = "umd"
+	ModuleSystem   ModuleKind = "system"
+	ModuleES2015   ModuleKind = "es2015"
+	ModuleES2020   ModuleKind = "es2020"
+	ModuleES2022   ModuleKind = "es2022"
+	ModuleESNext   ModuleKind = "esnext"
+	ModuleNode16   ModuleKind = "node16"
+	ModuleNodeNext ModuleKind = "nodenext"
+)
+
+// ModuleResolutionKind is the strategy used to resolve imported modules.
+type ModuleResolutionKind string
+
+const (
+	ModuleResolutionClassic  ModuleResolutionKind = "classic"
+	ModuleResolutionNode     ModuleResolutionKind = "node"
+	ModuleResolutionNode16   ModuleResolutionKind = "node16"
+	ModuleResolutionNodeNext ModuleResolutionKind = "nodenext"
+)
+
+// JSXEmit determines how JSX is emitted.
+type JSXEmit string
+
+const (
+	JSXPreserve    JSXEmit = "preserve"
+	JSXReact       JSXEmit = "react"
+	JSXReactNative JSXEmit = "react-native"
+	JSXReactJSX    JSXEmit = "react-jsx"
+	JSXReactJSXDev JSXEmit = "react-jsxdev"
+)
+
+// NewLineKind is the line ending used in emitted files.
+type NewLineKind string
+
+const (
+	NewLineCRLF NewLineKind = "crlf"
+	NewLineLF   NewLineKind = "lf"
+)
+
+// ImportsNotUsedAsValues determines how imports that are only used as types are emitted.
+type ImportsNotUsedAsValues string
+
+const (
+	ImportsNotUsedAsValuesRemove   ImportsNotUsedAsValues = "remove"
+	ImportsNotUsedAsValuesPreserve ImportsNotUsedAsValues = "preserve"
+	ImportsNotUsedAsValuesError    ImportsNotUsedAsValues = "error"
+)
+
+// Bool returns a pointer to b, for setting the boolean fields of CompilerOptions.
+func Bool(b bool) *bool {
+	return &b
+}
+
+// CompilerOptions are the typescript compiler options, as they would be written in the compilerOptions of a
+// tsconfig.json file. Unset fields are left to the compiler's defaults. Boolean options are pointers so that
+// they can be explicitly disabled, use Bool to set them. Options are validated against the typescript version
+// that they are used with, so options or values that the version doesn't support are reported as errors.
+type CompilerOptions struct {
+	AllowJs                          *bool                  `json:"allowJs,omitempty"`
+	AllowSyntheticDefaultImports     *bool                  `json:"allowSyntheticDefaultImports,omitempty"`
+	AllowUnreachableCode             *bool                  `json:"allowUnreachableCode,omitempty"`
+	AllowUnusedLabels                *bool                  `json:"allowUnusedLabels,omitempty"`
+	AlwaysStrict                     *bool                  `json:"alwaysStrict,omitempty"`
+	BaseURL                          string                 `json:"baseUrl,omitempty"`
+	CheckJs                          *bool                  `json:"checkJs,omitempty"`
+	Declaration                      *bool                  `json:"declaration,omitempty"`
+	DeclarationMap                   *bool                  `json:"declarationMap,omitempty"`
+	DownlevelIteration               *bool                  `json:"downlevelIteration,omitempty"`
+	EmitDecoratorMetadata            *bool                  `json:"emitDecoratorMetadata,omitempty"`
+	EsModuleInterop                  *bool                  `json:"esModuleInterop,omitempty"`
+	ExperimentalDecorators           *bool                  `json:"experimentalDecorators,omitempty"`
+	ForceConsistentCasingInFileNames *bool                  `json:"forceConsistentCasingInFileNames,omitempty"`
+	ImportHelpers                    *bool                  `json:"importHelpers,omitempty"`
+	ImportsNotUsedAsValues           ImportsNotUsedAsValues `json:"importsNotUsedAsValues,omitempty"`
+	InlineSourceMap                  *bool                  `json:"inlineSourceMap,omitempty"`
+	InlineSources                    *bool                  `json:"inlineSources,omitempty"`
+	IsolatedModules                  *bool                  `json:"isolatedModules,omitempty"`
+	JSX                              JSXEmit                `json:"jsx,omitempty"`
+	JSXFactory                       string                 `json:"jsxFactory,omitempty"`
+	JSXFragmentFactory               string                 `json:"jsxFragmentFactory,omitempty"`
+	JSXImportSource                  string                 `json:"jsxImportSource,omitempty"`
+	Lib                              []string               `json:"lib,omitempty"`
+	Module                           ModuleKind             `json:"module,omitempty"`
+	ModuleResolution                 ModuleResolutionKind   `json:"moduleResolution,omitempty"`
+	NewLine                          NewLineKind            `json:"newLine,omitempty"`
+	NoEmit                           *bool                  `json:"noEmit,omitempty"`
+	NoEmitHelpers                    *bool                  `json:"noEmitHelpers,omitempty"`
+	NoFallthroughCasesInSwitch       *bool                  `json:"noFallthroughCasesInSwitch,omitempty"`
+	NoImplicitAny                    *bool                  `json:"noImplicitAny,omitempty"`
+	NoImplicitReturns                *bool                  `json:"noImplicitReturns,omitempty"`
+	NoImplicitThis                   *bool                  `json:"noImplicitThis,omitempty"`
+	NoLib                            *bool                  `json:"noLib,omitempty"`
+	NoUnusedLocals                   *bool                  `json:"noUnusedLocals,omitempty"`
+	NoUnusedParameters               *bool                  `json:"noUnusedParameters,omitempty"`
+	OutDir                           string                 `json:"outDir,omitempty"`
+	Paths                            map[string][]string    `json:"paths,omitempty"`
+	PreserveConstEnums               *bool                  `json:"preserveConstEnums,omitempty"`
+	RemoveComments                   *bool                  `json:"removeComments,omitempty"`
+	ResolveJSONModule                *bool                  `json:"resolveJsonModule,omitempty"`
+	RootDir                          string                 `json:"rootDir,omitempty"`
+	SkipLibCheck                     *bool                  `json:"skipLibCheck,omitempty"`
+	SourceMap                        *bool                  `json:"sourceMap,omitempty"`
+	SourceRoot                       string                 `json:"sourceRoot,omitempty"`
+	Strict                           *bool                  `json:"strict,omitempty"`
+	StrictBindCallApply              *bool                  `json:"strictBindCallApply,omitempty"`
+	StrictFunctionTypes              *bool                  `json:"strictFunctionTypes,omitempty"`
+	StrictNullChecks                 *bool                  `json:"strictNullChecks,omitempty"`
+	StrictPropertyInitialization     *bool                  `json:"strictPropertyInitialization,omitempty"`
+	Target                           ScriptTarget           `json:"target,omitempty"`
+	TypeRoots                        []string               `json:"typeRoots,omitempty"`
+	Types                            []string               `json:"types,omitempty"`
+	UseDefineForClassFields          *bool                  `json:"useDefineForClassFields,omitempty"`
+	UseUnknownInCatchVariables       *bool                  `json:"useUnknownInCatchVariables,omitempty"`
+
+	// Other holds any compiler options that aren't covered by the fields above, keyed by their tsconfig.json
+	// name. These are validated the same way as the other options.
+	Other map[string]interface{} `json:"-"`
+}
+
+// toMap converts the options into the equivalent tsconfig.json compilerOptions object.
+func (o *CompilerOptions) toMap() map[string]interface{} {
+	options := make(map[string]interface{})
+	// The struct only has fields that always marshal successfully
+	b, _ := json.Marshal(o)
+	_ = json.Unmarshal(b, &options)
+	for k, v := range o.Other {
+		options[k] = v
+	}
+	return options
+}
+
+// ValidateCompilerOptions validates the compiler options against the typescript version selected by the
+// provided options and returns the errors reported by the compiler, such as unknown options or invalid values.
+func ValidateCompilerOptions(options CompilerOptions, opts ...TranspileOptionFunc) ([]Diagnostic, error) {
+	cfg, done, err := newCompilerConfig(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return validateCompilerOptions(cfg, &options)
+}
+
+func validateCompilerOptions(cfg *Config, options *CompilerOptions) ([]Diagnostic, error) {
+	optionBytes, err := json.Marshal(options.toMap())
+	if err != nil {
+		return nil, fmt.Errorf("marshalling compiler options: %w", err)
+	}
+	value, err := cfg.Runtime.RunString(fmt.Sprintf(`JSON.stringify((%s)(ts.convertCompilerOptionsFromJson(%s, "/").errors))`,
+		diagnosticsConverter, optionBytes))
+	if err != nil {
+		return nil, fmt.Errorf("validating compiler options: %w", err)
+	}
+	var diagnostics []Diagnostic
+	err = json.Unmarshal([]byte(value.String()), &diagnostics)
+	if err != nil {
+		return nil, fmt.Errorf("decoding compiler option diagnostics: %w", err)
+	}
+	return diagnostics, nil
+}
+
+// checkCompilerOptions returns a *DiagnosticsError if the config has typed compiler options that are not
+// valid for the typescript version loaded into the config's runtime. The options are only validated by the
+// runtime the first time they are checked, later checks of the same options reuse the result.
+func checkCompilerOptions(cfg *Config) error {
+	if cfg.CompilerOptions == nil {
+		return nil
+	}
+	optionBytes, err := json.Marshal(cfg.CompilerOptions.toMap())
+	if err != nil {
+		return fmt.Errorf("marshalling compiler options: %w", err)
+	}
+	if err, ok := cfg.checkedOptions[string(optionBytes)]; ok {
+		return err
+	}
+	diagnostics, err := validateCompilerOptions(cfg, cfg.CompilerOptions)
+	if err != nil {
+		return err
+	}
+	if hasErrors(diagnostics) {
+		err = fmt.Errorf("invalid compiler options: %w", &DiagnosticsError{Diagnostics: diagnostics})
+	}
+	if cfg.checkedOptions != nil {
+		cfg.checkedOptions[string(optionBytes)] = err
+	}
+	return err
+}
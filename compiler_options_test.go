@@ -0,0 +1,86 @@
+package typescript
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompilerOptions(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+	v4_9_3.Register(registry)
+
+	t.Run("valid", func(t *testing.T) {
+		output, err := TranspileString("export const a: number = 10;", WithRegistry(registry), WithVersion("v4.2.3"),
+			WithCompilerOptions(CompilerOptions{
+				Module:         ModuleAMD,
+				Target:         TargetES2015,
+				RemoveComments: Bool(true),
+			}))
+		require.NoError(t, err)
+		require.Contains(t, output, "define(\"default\"")
+		require.Contains(t, output, "exports.a = 10;")
+	})
+
+	t.Run("typo", func(t *testing.T) {
+		diagnostics, err := ValidateCompilerOptions(CompilerOptions{
+			Other: map[string]interface{}{"modlue": "amd"},
+		}, WithRegistry(registry), WithVersion("v4.2.3"))
+		require.NoError(t, err)
+		require.Len(t, diagnostics, 1)
+		require.Equal(t, 5025, diagnostics[0].Code)
+		require.Equal(t, "Unknown compiler option 'modlue'. Did you mean 'module'?", diagnostics[0].Message)
+	})
+
+	t.Run("unsupported by version", func(t *testing.T) {
+		options := CompilerOptions{Module: ModuleNode16}
+		_, err := TranspileString("let a: number = 10;", WithRegistry(registry), WithVersion("v4.2.3"),
+			WithCompilerOptions(options))
+		var diagnosticsErr *DiagnosticsError
+		require.True(t, errors.As(err, &diagnosticsErr))
+		require.Equal(t, 6046, diagnosticsErr.Diagnostics[0].Code)
+
+		diagnostics, err := ValidateCompilerOptions(options, WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.Empty(t, diagnostics)
+	})
+
+	t.Run("validated once", func(t *testing.T) {
+		transpiler, err := NewTranspiler(WithRegistry(registry), WithVersion("v4.2.3"),
+			WithCompilerOptions(CompilerOptions{Module: ModuleCommonJS}))
+		require.NoError(t, err)
+		_, err = transpiler.TranspileString("let a = 10;")
+		require.NoError(t, err)
+		_, err = transpiler.TranspileString("let a = 10;", WithCompilerOptions(CompilerOptions{Module: ModuleNode16}))
+		require.Error(t, err)
+
+		// Validating again would fail, the results of the earlier checks are reused instead
+		_, err = transpiler.cfg.Runtime.RunString(`ts.convertCompilerOptionsFromJson = function () { throw new Error("validated again"); };`)
+		require.NoError(t, err)
+		_, err = transpiler.TranspileString("let b = 10;")
+		require.NoError(t, err)
+		_, err = transpiler.TranspileString("let b = 10;", WithCompilerOptions(CompilerOptions{Module: ModuleNode16}))
+		var diagnosticsErr *DiagnosticsError
+		require.True(t, errors.As(err, &diagnosticsErr))
+		_, err = transpiler.TranspileString("let b = 10;", WithCompilerOptions(CompilerOptions{Module: ModuleAMD}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "validated again")
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		cfg := &Config{
+			CompileOptions:  map[string]interface{}{"module": "amd", "strict": true},
+			CompilerOptions: &CompilerOptions{Module: ModuleCommonJS, Paths: map[string][]string{"@/*": {"src/*"}}},
+		}
+		require.Equal(t, map[string]interface{}{
+			"module": "commonjs",
+			"strict": true,
+			"paths":  map[string]interface{}{"@/*": []interface{}{"src/*"}},
+		}, cfg.compilerOptions())
+	})
+}
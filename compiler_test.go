@@ -0,0 +1,116 @@
+package typescript
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+// minimalLib declares the global types the compiler requires to exist, it stands in for lib.d.ts
+const minimalLib = `
+interface Array<T> { length: number; [n: number]: T; }
+interface Boolean {}
+interface CallableFunction {}
+interface Function {}
+interface IArguments {}
+interface NewableFunction {}
+interface Number {}
+interface Object {}
+interface RegExp {}
+interface String {}
+`
+
+func TestCheck(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+
+	fsys := fstest.MapFS{
+		"node_modules/typescript/lib/lib.d.ts": {Data: []byte(minimalLib)},
+		"src/math.ts":                          {Data: []byte("export function add(a: number, b: number): number { return a + b; }")},
+		"src/index.ts":                         {Data: []byte("import { add } from './math';\nconst s: string = add(1, 2);")},
+		"src/valid.ts":                         {Data: []byte("import { add } from './math';\nexport const n: number = add(1, 2);")},
+	}
+
+	t.Run("type error", func(t *testing.T) {
+		result, err := Check(context.Background(), fsys, []string{"src/index.ts"}, WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.Len(t, result.Diagnostics, 1)
+		d := result.Diagnostics[0]
+		require.Equal(t, 2322, d.Code)
+		require.Equal(t, "/src/index.ts", d.File)
+		require.Equal(t, 2, d.Line)
+		require.Equal(t, 7, d.Column)
+		require.Equal(t, "Type 'number' is not assignable to type 'string'.", d.Message)
+		require.Empty(t, result.Files)
+	})
+
+	t.Run("fail on errors", func(t *testing.T) {
+		_, err := Check(context.Background(), fsys, []string{"src/index.ts"}, WithRegistry(registry), WithVersion("v4.9.3"),
+			WithFailOnDiagnosticErrors())
+		var diagnosticsErr *DiagnosticsError
+		require.True(t, errors.As(err, &diagnosticsErr))
+	})
+
+	t.Run("compile", func(t *testing.T) {
+		result, err := Compile(context.Background(), fsys, []string{"src/valid.ts"}, WithRegistry(registry), WithVersion("v4.9.3"),
+			WithCompileOptions(map[string]interface{}{
+				"module": "commonjs",
+				"strict": true,
+			}))
+		require.NoError(t, err)
+		require.Empty(t, result.Diagnostics)
+		require.Len(t, result.Files, 2)
+		require.Contains(t, result.Files["/src/valid.js"], "exports.n = (0, math_1.add)(1, 2);")
+		require.Contains(t, result.Files["/src/math.js"], "function add(a, b)")
+	})
+
+	t.Run("registered libs", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte(minimalLib))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		registry := versions.NewRegistry()
+		v4_9_3.Register(registry)
+		registry.RegisterLibs("v4.9.3", versions.GzipFS(fstest.MapFS{
+			"lib.d.ts.gz": {Data: buf.Bytes()},
+		}))
+		result, err := Check(context.Background(), fstest.MapFS{
+			"index.ts": {Data: []byte("let a: number[] = [10];")},
+		}, []string{"index.ts"}, WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.Empty(t, result.Diagnostics)
+	})
+
+	t.Run("bundled libs", func(t *testing.T) {
+		if v4_9_3.Libs == nil {
+			t.Skip("the lib files of v4.9.3 aren't vendored, run go generate ./versions/...")
+		}
+		result, err := Check(context.Background(), fstest.MapFS{
+			"index.ts": {Data: []byte("export const a: Promise<number[]> = Promise.resolve([1, 2].map((n) => n * 2));")},
+		}, []string{"index.ts"}, WithRegistry(registry), WithVersion("v4.9.3"),
+			WithCompileOptions(map[string]interface{}{"target": "es2015"}))
+		require.NoError(t, err)
+		require.Empty(t, result.Diagnostics)
+	})
+
+	t.Run("missing lib", func(t *testing.T) {
+		// The compiler is registered without its lib files
+		registry := versions.NewRegistry()
+		registry.RegisterLoader(v4_9_3.Tag, v4_9_3.Source)
+		result, err := Check(context.Background(), fstest.MapFS{
+			"index.ts": {Data: []byte("let a = 10;")},
+		}, []string{"index.ts"}, WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Diagnostics)
+		require.Equal(t, 2318, result.Diagnostics[0].Code)
+	})
+}
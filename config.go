@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"github.com/clarkmcc/go-typescript/utils"
 	"github.com/clarkmcc/go-typescript/versions"
-	_ "github.com/clarkmcc/go-typescript/versions/v4.9.3"
 	"github.com/dop251/goja"
 )
 
@@ -14,17 +13,52 @@ type TranspileOptionFunc func(*Config)
 
 // Config defines the behavior of the typescript compiler.
 type Config struct {
-	CompileOptions    map[string]interface{}
+	CompileOptions map[string]interface{}
+	// CompilerOptions are typed compiler options that are validated against the typescript version before
+	// they are used. They take precedence over any of the same options in CompileOptions.
+	CompilerOptions   *CompilerOptions
 	TypescriptVersion string
 	Registry          versions.Registry
 	Runtime           *goja.Runtime
 
+	// FileName is the name of the file being transpiled. The compiler uses the extension to decide how to
+	// parse the file (for example .tsx enables JSX) and the module format of .mts and .cts files, and the
+	// name is used in diagnostics and source maps.
+	FileName string
+
 	// If a module is exported by the typescript compiler, this is the name the module will be called
 	ModuleName string
 
 	// Verbose enables built-in verbose logging for debugging purposes.
 	Verbose bool
 
+	// FailOnDiagnosticErrors causes transpilation to fail with a *DiagnosticsError if the compiler reports
+	// any error diagnostics, rather than returning whatever javascript the compiler managed to emit.
+	FailOnDiagnosticErrors bool
+
+	// SourceMap enables source map generation. The source map is returned alongside the transpiled code.
+	SourceMap bool
+
+	// InlineSourceMap enables source map generation with the source map embedded in the transpiled code
+	// as a data URL. The source map is also returned alongside the transpiled code.
+	InlineSourceMap bool
+
+	// Transformers are custom javascript transformers that are run when transpiling.
+	Transformers Transformers
+
+	// Visitors are called for every node of the script's syntax tree before it is transpiled, and can remove
+	// or replace nodes.
+	Visitors []Visitor
+
+	// Declarations are ambient declaration files, such as those generated by a DeclarationGenerator, that are
+	// included when type checking with Check, Compile and LanguageService. The keys are the file names, which
+	// should end in .d.ts.
+	Declarations map[string]string
+
+	// Cache is consulted before transpiling, and transpile results are stored in it. Transpiling is
+	// skipped entirely, including loading the compiler, when the cache has a result.
+	Cache TranspileCache
+
 	// PreventCancellation indicates that the transpiler should not handle context cancellation. This
 	// should be used when external runtimes are configured AND cancellation is handled by those runtimes.
 	PreventCancellation bool
@@ -37,6 +71,11 @@ type Config struct {
 	// the transpiler caller provides their own runtime with a custom implementation of atob.
 	decoderName string
 
+	// checkedOptions holds the result of checkCompilerOptions for the typed compiler options the config's
+	// runtime has validated, keyed by the JSON encoded options. The map is shared by the copies of a
+	// Transpiler's config, which are only used while holding the Transpiler's lock.
+	checkedOptions map[string]error
+
 	// Used only for testing to ensure that the compiler can handle config initialization failures
 	failOnInitialize bool
 }
@@ -55,14 +94,69 @@ func (c *Config) Initialize() error {
 	}))
 }
 
-// NewDefaultConfig creates a new instance of the Config struct with default values and the latest
-// typescript source code.s
+// compilerOptions returns the compile options that should be passed to the compiler, including any
+// options that are implied by other config values.
+func (c *Config) compilerOptions() map[string]interface{} {
+	if !c.SourceMap && !c.InlineSourceMap && c.CompilerOptions == nil {
+		return c.CompileOptions
+	}
+	options := make(map[string]interface{}, len(c.CompileOptions)+1)
+	for k, v := range c.CompileOptions {
+		options[k] = v
+	}
+	if c.CompilerOptions != nil {
+		for k, v := range c.CompilerOptions.toMap() {
+			options[k] = v
+		}
+	}
+	// The compiler rejects sourceMap and inlineSourceMap together, so the config's choice replaces the other
+	if c.InlineSourceMap {
+		delete(options, "sourceMap")
+		options["inlineSourceMap"] = true
+	} else if c.SourceMap {
+		delete(options, "inlineSourceMap")
+		options["sourceMap"] = true
+	}
+	return options
+}
+
+// inlineSourceMap returns true if an inline source map is requested by the config or the compile options. As
+// in compilerOptions, a source map requested by the config replaces an inline source map in the compile options.
+func (c *Config) inlineSourceMap() bool {
+	if c.InlineSourceMap || c.SourceMap {
+		return c.InlineSourceMap
+	}
+	if c.CompilerOptions != nil && c.CompilerOptions.InlineSourceMap != nil {
+		return *c.CompilerOptions.InlineSourceMap
+	}
+	inline, _ := c.CompileOptions["inlineSourceMap"].(bool)
+	return inline
+}
+
+// resolveVersion replaces a version constraint in the config with the tag of the registered version that
+// satisfies it, if the registry is a versions.Resolver.
+func (c *Config) resolveVersion() error {
+	r, ok := c.Registry.(versions.Resolver)
+	if !ok {
+		return nil
+	}
+	tag, err := r.Resolve(c.TypescriptVersion)
+	if err != nil {
+		return err
+	}
+	c.TypescriptVersion = tag
+	return nil
+}
+
+// NewDefaultConfig creates a new instance of the Config struct with default values, using typescript v4.9.3
+// from versions.DefaultRegistry. The version is registered unless the program is built with the tsnodefault
+// tag, in which case the registry only contains the versions the program imports.
 func NewDefaultConfig() *Config {
 	return &Config{
 		Runtime:           goja.New(),
 		CompileOptions:    nil,
 		TypescriptVersion: "v4.9.3",
-		Registry:          versions.NewRegistry(),
+		Registry:          versions.DefaultRegistry,
 		ModuleName:        "default",
 	}
 }
@@ -73,7 +167,9 @@ func WithRegistry(registry versions.Registry) TranspileOptionFunc {
 	}
 }
 
-// WithVersion loads the provided tagged typescript source from the default registry
+// WithVersion loads the provided tagged typescript source from the default registry. If the registry is a
+// versions.Resolver, the tag may also be a version constraint such as "^4.2", "~4.1.4", "4.x" or "latest",
+// which is resolved to the highest registered version that satisfies it.
 func WithVersion(tag string) TranspileOptionFunc {
 	return func(config *Config) {
 		config.TypescriptVersion = tag
@@ -87,6 +183,28 @@ func WithCompileOptions(options map[string]interface{}) TranspileOptionFunc {
 	}
 }
 
+// WithCompilerOptions sets typed compiler options that are validated against the typescript version before
+// they are passed to the compiler. Unknown options, and values not supported by the version, are errors.
+func WithCompilerOptions(options CompilerOptions) TranspileOptionFunc {
+	return func(config *Config) {
+		config.CompilerOptions = &options
+	}
+}
+
+// WithTSConfig sets the compile options to the options parsed from a tsconfig.json file by LoadTSConfig.
+func WithTSConfig(tsconfig *TSConfig) TranspileOptionFunc {
+	return func(config *Config) {
+		config.CompileOptions = tsconfig.CompileOptions
+	}
+}
+
+// WithCache sets the cache used to store and look up transpile results.
+func WithCache(cache TranspileCache) TranspileOptionFunc {
+	return func(config *Config) {
+		config.Cache = cache
+	}
+}
+
 // WithRuntime allows you to over-ride the default runtime
 func WithRuntime(runtime *goja.Runtime) TranspileOptionFunc {
 	return func(config *Config) {
@@ -94,6 +212,13 @@ func WithRuntime(runtime *goja.Runtime) TranspileOptionFunc {
 	}
 }
 
+// WithFileName sets the name of the file being transpiled, see Config.FileName.
+func WithFileName(name string) TranspileOptionFunc {
+	return func(config *Config) {
+		config.FileName = name
+	}
+}
+
 // WithModuleName determines the module name applied to the typescript module if applicable. This is only needed to
 // customize the module name if the typescript module mode is AMD or SystemJS.
 func WithModuleName(name string) TranspileOptionFunc {
@@ -109,6 +234,43 @@ func WithPreventCancellation() TranspileOptionFunc {
 	}
 }
 
+// WithFailOnDiagnosticErrors causes the transpiler to return a *DiagnosticsError if the typescript compiler
+// reports any errors, such as syntax errors, while transpiling.
+func WithFailOnDiagnosticErrors() TranspileOptionFunc {
+	return func(config *Config) {
+		config.FailOnDiagnosticErrors = true
+	}
+}
+
+// WithSourceMap enables source map generation, the parsed source map is returned in the TranspileResult.
+func WithSourceMap() TranspileOptionFunc {
+	return func(config *Config) {
+		config.SourceMap = true
+	}
+}
+
+// WithInlineSourceMap enables source map generation with the source map inlined into the transpiled code
+// as a data URL. The parsed source map is also returned in the TranspileResult.
+func WithInlineSourceMap() TranspileOptionFunc {
+	return func(config *Config) {
+		config.InlineSourceMap = true
+	}
+}
+
+// WithDeclarations adds an ambient declaration file that is included when type checking, see
+// Config.Declarations.
+func WithDeclarations(name, content string) TranspileOptionFunc {
+	return func(config *Config) {
+		// The map is copied so that per-call options never modify the options of a shared config
+		declarations := make(map[string]string, len(config.Declarations)+1)
+		for k, v := range config.Declarations {
+			declarations[k] = v
+		}
+		declarations[compilerPath(name)] = content
+		config.Declarations = declarations
+	}
+}
+
 // withFailOnInitialize used to test a config initialization failure. This is not exported because
 // it's used only for testing.
 func withFailOnInitialize() TranspileOptionFunc {
@@ -16,6 +16,7 @@ import (
 	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"testing/fstest"
 )
 
 func TestConfig_Initialize(t *testing.T) {
@@ -29,7 +30,7 @@ func TestConfig_Initialize(t *testing.T) {
 func TestVersionLoading(t *testing.T) {
 	registry := versions.NewRegistry()
 
-	sources := map[string]string{
+	sources := map[string]versions.Loader{
 		"v3.8.3": v3_8_3.Source,
 		"v3.9.9": v3_9_9.Source,
 		"v4.1.2": v4_1_2.Source,
@@ -44,7 +45,7 @@ func TestVersionLoading(t *testing.T) {
 	}
 
 	for tag, source := range sources {
-		registry.Register(tag, source)
+		registry.RegisterLoader(tag, source)
 	}
 
 	for tag, _ := range sources {
@@ -56,9 +57,57 @@ func TestVersionLoading(t *testing.T) {
 	}
 }
 
+func TestVersionConstraints(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_1_5.Register(registry)
+	v4_2_3.Register(registry)
+
+	for constraint, expected := range map[string]string{"^4.1": "v4.2.3", "~4.1.2": "v4.1.5", "latest": "v4.2.3"} {
+		t.Run(constraint, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			WithRegistry(registry)(cfg)
+			WithVersion(constraint)(cfg)
+			require.NoError(t, cfg.resolveVersion())
+			require.Equal(t, expected, cfg.TypescriptVersion)
+
+			output, err := TranspileString("let a: number = 10;", WithRegistry(registry), WithVersion(constraint))
+			require.NoError(t, err)
+			require.Equal(t, "var a = 10;", output)
+		})
+	}
+
+	_, err := TranspileString("let a: number = 10;", WithRegistry(registry), WithVersion("^5"))
+	require.EqualError(t, err, "getting typescript source: unsupported version tag '^5', the closest registered versions are [v4.2.3 v4.1.5]")
+}
+
+func TestNpmPackageLoading(t *testing.T) {
+	source, err := v4_2_3.Source()
+	require.NoError(t, err)
+	registry := versions.NewRegistry()
+	tag, err := versions.RegisterNpmPackage(registry, fstest.MapFS{
+		"package.json":      {Data: []byte(`{"name": "typescript", "version": "4.2.3"}`)},
+		"lib/typescript.js": {Data: []byte(source)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "v4.2.3", tag)
+
+	output, err := TranspileString("let a: number = 10;", WithRegistry(registry), WithVersion(tag))
+	require.NoError(t, err)
+	require.Equal(t, "var a = 10;", output)
+
+	tag, err = versions.RegisterNpmPackage(registry, fstest.MapFS{
+		"package.json":      {Data: []byte(`{"name": "typescript", "version": "4.2.4"}`)},
+		"lib/typescript.js": {Data: []byte(source)},
+	})
+	require.NoError(t, err)
+	_, err = TranspileString("let a: number = 10;", WithRegistry(registry), WithVersion(tag))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "package.json version '4.2.4' doesn't match ts.version '4.2.3'")
+}
+
 func TestWithModuleName(t *testing.T) {
 	registry := versions.NewRegistry()
-	registry.Register("v4.9.3", v4_9_3.Source)
+	v4_9_3.Register(registry)
 	output, err := TranspileString("let a: number = 10;",
 		WithModuleName("myModuleName"),
 		WithRegistry(registry),
@@ -0,0 +1,379 @@
+package typescript
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+var (
+	typeValue           = reflect.TypeOf((*goja.Value)(nil)).Elem()
+	typeObject          = reflect.TypeOf((*goja.Object)(nil))
+	typeCallable        = reflect.TypeOf((*goja.Callable)(nil)).Elem()
+	typeFunctionCall    = reflect.TypeOf(goja.FunctionCall{})
+	typeConstructorCall = reflect.TypeOf(goja.ConstructorCall{})
+	typeTime            = reflect.TypeOf(time.Time{})
+	typeBytes           = reflect.TypeOf([]byte(nil))
+	typeError           = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// DeclarationOptionFunc configures a DeclarationGenerator.
+type DeclarationOptionFunc func(*DeclarationGenerator)
+
+// WithFieldNameMapper sets the goja.FieldNameMapper that the runtime the values are exposed to uses, so that
+// the declared property names match the names scripts see. A nil mapper, the default, matches goja's default of
+// using the Go names of exported fields and methods.
+func WithFieldNameMapper(mapper goja.FieldNameMapper) DeclarationOptionFunc {
+	return func(g *DeclarationGenerator) {
+		g.mapper = mapper
+	}
+}
+
+// WithTypesNamespace sets the namespace that the interfaces generated for named Go types are declared in, which
+// defaults to "go". The namespace prevents the interfaces from merging with global types of the same name, such
+// as Date or Error.
+func WithTypesNamespace(namespace string) DeclarationOptionFunc {
+	return func(g *DeclarationGenerator) {
+		g.namespace = namespace
+	}
+}
+
+// DeclarationGenerator generates a typescript declaration file describing Go values that are exposed to
+// scripts, such as with goja.Runtime.Set. The types are found with reflection and follow goja's conversion
+// rules, for example:
+//
+//   - Numbers, strings and booleans, including named types such as time.Duration, are number, string and
+//     boolean.
+//   - Slices and arrays are arrays, and maps with string or numeric keys are objects with an index signature.
+//   - Named structs and interfaces are interfaces in the types namespace, with a property for each exported
+//     field, including the fields of embedded structs, and a method for each exported method. The methods of
+//     both value and pointer receivers are included.
+//   - Functions drop a trailing error result, which goja throws instead, and return an array when there are
+//     multiple results.
+//   - time.Time is a Go object with time.Time's methods when it is read by a script, and can be passed to Go
+//     as a Date or a date string.
+//   - goja.Value, *goja.Object and empty interfaces are any.
+//
+// The generated file is a global declaration file that can be added to type checking with WithDeclarations.
+type DeclarationGenerator struct {
+	mapper    goja.FieldNameMapper
+	namespace string
+
+	globals     []string
+	modules     map[string][]string
+	moduleOrder []string
+
+	typeNames map[reflect.Type]string
+	usedNames map[string]bool
+	types     []string
+}
+
+// NewDeclarationGenerator creates a DeclarationGenerator with no declarations.
+func NewDeclarationGenerator(opts ...DeclarationOptionFunc) *DeclarationGenerator {
+	g := &DeclarationGenerator{
+		namespace: "go",
+		modules:   make(map[string][]string),
+		typeNames: make(map[reflect.Type]string),
+		usedNames: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// AddGlobal declares a global variable, or a global function if value is a func, with the type of value.
+func (g *DeclarationGenerator) AddGlobal(name string, value interface{}) {
+	g.globals = append(g.globals, g.declaration("declare ", name, value))
+}
+
+// AddModuleExport declares an export of the module with the provided name, which scripts can import with
+// import { name } from "module".
+func (g *DeclarationGenerator) AddModuleExport(module, name string, value interface{}) {
+	if _, ok := g.modules[module]; !ok {
+		g.moduleOrder = append(g.moduleOrder, module)
+	}
+	g.modules[module] = append(g.modules[module], g.declaration("export ", name, value))
+}
+
+// String returns the declaration file.
+func (g *DeclarationGenerator) String() string {
+	var b strings.Builder
+	for _, d := range g.globals {
+		b.WriteString(d)
+		b.WriteString("\n")
+	}
+	for _, module := range g.moduleOrder {
+		fmt.Fprintf(&b, "declare module %s {\n", quote(module))
+		for _, d := range g.modules[module] {
+			b.WriteString("    ")
+			b.WriteString(d)
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n")
+	}
+	if len(g.types) > 0 {
+		fmt.Fprintf(&b, "declare namespace %s {\n", g.namespace)
+		for _, t := range g.types {
+			b.WriteString(t)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// declaration returns the declaration of a variable or function with the provided prefix, such as "declare ".
+func (g *DeclarationGenerator) declaration(prefix, name string, value interface{}) string {
+	if value == nil {
+		return fmt.Sprintf("%sconst %s: null;", prefix, name)
+	}
+	t := reflect.TypeOf(value)
+	if t.Kind() == reflect.Func && !isNativeFunc(t) {
+		return fmt.Sprintf("%sfunction %s%s;", prefix, name, g.signature(t, false, ":"))
+	}
+	return fmt.Sprintf("%sconst %s: %s;", prefix, name, g.typeOf(t, false))
+}
+
+// typeOf returns the typescript type of a Go type. Input types are the types accepted by goja when converting a
+// javascript value to a Go value, such as the arguments of a function, which are more lenient than the types of
+// Go values converted to javascript.
+func (g *DeclarationGenerator) typeOf(t reflect.Type, input bool) string {
+	switch t {
+	case typeValue, typeObject:
+		return "any"
+	case typeCallable:
+		return "(...args: any[]) => any"
+	case typeTime:
+		if input {
+			return "Date | string"
+		}
+	case typeBytes:
+		if input {
+			return "number[] | string"
+		}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Ptr:
+		// The methods of pointers to structs are included in the struct's interface
+		return g.typeOf(t.Elem(), input)
+	case reflect.Slice, reflect.Array:
+		elem := g.typeOf(t.Elem(), input)
+		if strings.ContainsAny(elem, " |") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		if t.NumMethod() > 0 {
+			// goja exposes the methods of maps with methods, rather than their keys
+			return g.named(t)
+		}
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return fmt.Sprintf("{ [key: string]: %s }", g.typeOf(t.Elem(), input))
+		}
+		return "{}"
+	case reflect.Struct:
+		return g.named(t)
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "any"
+		}
+		return g.named(t)
+	case reflect.Func:
+		if isNativeFunc(t) {
+			if t.In(0) == typeConstructorCall {
+				return "{ new (...args: any[]): any; (...args: any[]): any }"
+			}
+			return "(...args: any[]) => any"
+		}
+		return g.signature(t, false, " =>")
+	}
+	return "any"
+}
+
+// named returns the name of the interface for a struct, interface or map type, generating the interface the
+// first time the type is seen. Anonymous types are returned as object type literals.
+func (g *DeclarationGenerator) named(t reflect.Type) string {
+	if name, ok := g.typeNames[t]; ok {
+		return g.namespace + "." + name
+	}
+	if t.Name() == "" {
+		return g.members(t, "")
+	}
+	name := t.Name()
+	if t == typeError {
+		name = "GoError"
+	}
+	// Generic instantiations are named like List[int]
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	base := name
+	for i := 2; g.usedNames[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	g.usedNames[name] = true
+	g.typeNames[t] = name
+	// The interface is declared in the order the types are first seen, before any types that its members refer to
+	index := len(g.types)
+	g.types = append(g.types, "")
+	g.types[index] = fmt.Sprintf("    interface %s %s\n", name, g.members(t, "    "))
+	return g.namespace + "." + name
+}
+
+// members returns the object type literal with the fields and methods of a type, indented by indent.
+func (g *DeclarationGenerator) members(t reflect.Type, indent string) string {
+	var members []string
+	if t.Kind() == reflect.Struct {
+		var fields []structField
+		g.fields(t, 0, make(map[string]int), &fields)
+		for _, f := range fields {
+			members = append(members, propertyName(f.name)+": "+g.typeOf(f.typ, false)+";")
+		}
+	}
+	methods := t
+	if t.Kind() == reflect.Struct {
+		methods = reflect.PtrTo(t)
+	}
+	for i := 0; i < methods.NumMethod(); i++ {
+		m := methods.Method(i)
+		if !ast.IsExported(m.Name) {
+			continue
+		}
+		name := m.Name
+		if g.mapper != nil {
+			name = g.mapper.MethodName(methods, m)
+			if name == "" {
+				continue
+			}
+		}
+		members = append(members, propertyName(name)+g.signature(m.Type, methods.Kind() != reflect.Interface, ":")+";")
+	}
+	if len(members) == 0 {
+		return "{}"
+	}
+	if indent == "" {
+		return "{ " + strings.Join(members, " ") + " }"
+	}
+	return "{\n" + indent + "    " + strings.Join(members, "\n"+indent+"    ") + "\n" + indent + "}"
+}
+
+// structField is an exported field of a struct, or of a struct embedded in it, as it is seen by goja.
+type structField struct {
+	name  string
+	typ   reflect.Type
+	depth int
+}
+
+// fields adds the exported fields of a struct, including the fields of embedded structs, to fields. When fields
+// of embedded structs have the same name the shallowest field wins, as it does in goja. The index of each field
+// name in fields is recorded in seen.
+func (g *DeclarationGenerator) fields(t reflect.Type, depth int, seen map[string]int, fields *[]structField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !ast.IsExported(field.Name) {
+			continue
+		}
+		name := field.Name
+		if g.mapper != nil {
+			name = g.mapper.FieldName(t, field)
+		}
+		f := structField{name: name, typ: field.Type, depth: depth}
+		if j, ok := seen[name]; name != "" && !ok {
+			seen[name] = len(*fields)
+			*fields = append(*fields, f)
+		} else if name != "" && (*fields)[j].depth > f.depth {
+			(*fields)[j] = f
+		}
+		if field.Anonymous {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, depth+1, seen, fields)
+			}
+		}
+	}
+}
+
+// signature returns the parameters and return type of a function, separated by sep. The first parameter of a
+// method's type is the receiver, which is skipped.
+func (g *DeclarationGenerator) signature(t reflect.Type, method bool, sep string) string {
+	var params []string
+	first := 0
+	if method {
+		first = 1
+	}
+	for i := first; i < t.NumIn(); i++ {
+		name := fmt.Sprintf("arg%d", i-first)
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			elem := g.typeOf(t.In(i).Elem(), true)
+			if strings.ContainsAny(elem, " |") {
+				elem = "(" + elem + ")"
+			}
+			params = append(params, fmt.Sprintf("...%s: %s[]", name, elem))
+			continue
+		}
+		params = append(params, fmt.Sprintf("%s: %s", name, g.typeOf(t.In(i), true)))
+	}
+	var results []string
+	for i := 0; i < t.NumOut(); i++ {
+		if i == t.NumOut()-1 && t.Out(i) == typeError {
+			// A non-nil error is thrown rather than returned
+			continue
+		}
+		results = append(results, g.typeOf(t.Out(i), false))
+	}
+	result := "void"
+	switch len(results) {
+	case 0:
+	case 1:
+		result = results[0]
+	default:
+		result = "[" + strings.Join(results, ", ") + "]"
+	}
+	return fmt.Sprintf("(%s)%s %s", strings.Join(params, ", "), sep, result)
+}
+
+// isNativeFunc returns true if t is one of the function types that goja passes the raw javascript call to.
+func isNativeFunc(t reflect.Type) bool {
+	if t.Kind() != reflect.Func || t.NumIn() < 1 || t.NumIn() > 2 || t.IsVariadic() {
+		return false
+	}
+	return t.In(0) == typeFunctionCall || t.In(0) == typeConstructorCall
+}
+
+// propertyName returns name as a property name, quoting it if it isn't a valid identifier.
+func propertyName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return quote(name)
+		}
+	}
+	if name == "" {
+		return quote(name)
+	}
+	return name
+}
+
+// quote returns s as a javascript string literal.
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
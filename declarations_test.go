@@ -0,0 +1,144 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/require"
+)
+
+type DeclarationsAudit struct {
+	CreatedBy string
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type declarationsUser struct {
+	DeclarationsAudit
+	Name     string            `json:"name"`
+	Email    string            `json:"email,omitempty"`
+	Password string            `json:"-"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]int    `json:"labels"`
+	Manager  *declarationsUser `json:"manager"`
+	Timeout  time.Duration     `json:"timeout"`
+	internal string
+}
+
+func (u *declarationsUser) Greet(greeting string) string {
+	return greeting + " " + u.Name
+}
+
+func TestDeclarationGenerator(t *testing.T) {
+	g := NewDeclarationGenerator(WithFieldNameMapper(goja.TagFieldNameMapper("json", true)))
+	g.AddGlobal("user", &declarationsUser{})
+	g.AddGlobal("version", "1.0.0")
+	g.AddGlobal("lookup", func(id int64) (*declarationsUser, error) { return nil, nil })
+	g.AddGlobal("split", func(s string, seps ...string) ([]string, int) { return nil, 0 })
+	g.AddGlobal("raw", func(call goja.FunctionCall) goja.Value { return nil })
+	g.AddModuleExport("host:time", "since", func(t time.Time) time.Duration { return 0 })
+	g.AddModuleExport("host:time", "check", func() error { return nil })
+	g.AddModuleExport("host:time", "lastError", errors.New("failed"))
+
+	out := g.String()
+	require.Equal(t, []string{
+		"declare const user: go.declarationsUser;",
+		"declare const version: string;",
+		"declare function lookup(arg0: number): go.declarationsUser;",
+		"declare function split(arg0: string, ...arg1: string[]): [string[], number];",
+		"declare const raw: (...args: any[]) => any;",
+		`declare module "host:time" {`,
+		"    export function since(arg0: Date | string): number;",
+		"    export function check(): void;",
+		"    export const lastError: go.errorString;",
+		"}",
+		"declare namespace go {",
+		"    interface declarationsUser {",
+		"        createdAt: go.Time;",
+		"        name: string;",
+		"        email: string;",
+		"        tags: string[];",
+		"        labels: { [key: string]: number };",
+		"        manager: go.declarationsUser;",
+		"        timeout: number;",
+		"        greet(arg0: string): string;",
+		"    }",
+		"    interface Time {",
+	}, strings.Split(out, "\n")[:22])
+	require.Contains(t, out, "        unixNano(): number;\n")
+	require.Contains(t, out, "    interface errorString {\n        error(): string;\n    }\n")
+}
+
+func TestDeclarationGenerator_DefaultMapper(t *testing.T) {
+	type point struct {
+		X, Y float64
+	}
+	g := NewDeclarationGenerator(WithTypesNamespace("host"))
+	g.AddGlobal("origin", point{})
+	g.AddGlobal("anonymous", struct {
+		A    bool
+		B    interface{}
+		Next func(error) []interface{ Close() error }
+	}{})
+	require.Equal(t, strings.Join([]string{
+		"declare const origin: host.point;",
+		"declare const anonymous: { A: boolean; B: any; Next: (arg0: host.GoError) => ({ Close(): void; })[]; };",
+		"declare namespace host {",
+		"    interface point {",
+		"        X: number;",
+		"        Y: number;",
+		"    }",
+		"    interface GoError {",
+		"        Error(): string;",
+		"    }",
+		"}",
+		"",
+	}, "\n"), g.String())
+}
+
+func TestWithDeclarations(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	lib := minimalLib + "interface Date { getTime(): number; }\n"
+
+	g := NewDeclarationGenerator(WithFieldNameMapper(goja.TagFieldNameMapper("json", true)))
+	g.AddGlobal("user", &declarationsUser{})
+	g.AddModuleExport("host:time", "since", func(t time.Time) time.Duration { return 0 })
+	opts := []TranspileOptionFunc{WithRegistry(registry), WithVersion("v4.9.3"), WithDeclarations("host.d.ts", g.String())}
+
+	fsys := fstest.MapFS{
+		"node_modules/typescript/lib/lib.d.ts": {Data: []byte(lib)},
+		"valid.ts": {Data: []byte(strings.Join([]string{
+			`import { since } from "host:time";`,
+			`declare const now: Date;`,
+			`export const n: number = since(now) + user.manager.labels["a"];`,
+			`export const s: string = user.greet(user.tags[0]);`,
+		}, "\n"))},
+		"invalid.ts": {Data: []byte(`export const s: string = user.Name;`)},
+	}
+
+	result, err := Check(context.Background(), fsys, []string{"valid.ts"}, opts...)
+	require.NoError(t, err)
+	require.Empty(t, result.Diagnostics)
+
+	result, err = Check(context.Background(), fsys, []string{"invalid.ts"}, opts...)
+	require.NoError(t, err)
+	require.Len(t, result.Diagnostics, 1)
+	require.Equal(t, 2551, result.Diagnostics[0].Code)
+
+	registry.RegisterLibs("v4.9.3", fstest.MapFS{"lib.d.ts": {Data: []byte(lib)}})
+	service, err := NewLanguageService(opts...)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/host.d.ts"}, service.Files())
+	service.SetFile("index.ts", "user.greet(10);")
+	diagnostics, err := service.Diagnostics("index.ts")
+	require.NoError(t, err)
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, 2345, diagnostics[0].Code)
+}
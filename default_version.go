@@ -0,0 +1,9 @@
+//go:build !tsnodefault
+// +build !tsnodefault
+
+package typescript
+
+// The default version is registered in versions.DefaultRegistry, so that the default config works without
+// any options. Building with the tsnodefault tag leaves it out, so that programs only link the versions
+// they import.
+import _ "github.com/clarkmcc/go-typescript/versions/v4.9.3"
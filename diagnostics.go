@@ -0,0 +1,130 @@
+package typescript
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiagnosticCategory mirrors the typescript compiler's ts.DiagnosticCategory enum.
+type DiagnosticCategory int
+
+const (
+	DiagnosticCategoryWarning DiagnosticCategory = iota
+	DiagnosticCategoryError
+	DiagnosticCategorySuggestion
+	DiagnosticCategoryMessage
+)
+
+func (c DiagnosticCategory) String() string {
+	switch c {
+	case DiagnosticCategoryWarning:
+		return "warning"
+	case DiagnosticCategoryError:
+		return "error"
+	case DiagnosticCategorySuggestion:
+		return "suggestion"
+	case DiagnosticCategoryMessage:
+		return "message"
+	default:
+		return fmt.Sprintf("category(%d)", int(c))
+	}
+}
+
+// DiagnosticMessageChain is a nested diagnostic message as produced by the typescript compiler
+// when a diagnostic has additional context (for example, why one type is not assignable to another).
+type DiagnosticMessageChain struct {
+	Message  string                   `json:"message"`
+	Category DiagnosticCategory       `json:"category"`
+	Code     int                      `json:"code"`
+	Next     []DiagnosticMessageChain `json:"next,omitempty"`
+}
+
+// Diagnostic is a single error, warning or message reported by the typescript compiler.
+type Diagnostic struct {
+	Code     int                `json:"code"`
+	Category DiagnosticCategory `json:"category"`
+	// Message is the flattened message text, including all messages in the MessageChain.
+	Message string `json:"message"`
+	// MessageChain is only set if the compiler reported a chained message.
+	MessageChain *DiagnosticMessageChain `json:"chain,omitempty"`
+	// File is the name of the file the diagnostic was reported for, if any.
+	File string `json:"file,omitempty"`
+	// Line and Column are the 1-based position of the diagnostic, or zero if the diagnostic
+	// does not have a position.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+	// Start and Length are the character offset and length of the diagnostic in the source file.
+	Start  int `json:"start,omitempty"`
+	Length int `json:"length,omitempty"`
+}
+
+// String formats the diagnostic the same way tsc does, for example:
+//
+//	module.ts(1,9): error TS1005: ';' expected.
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if d.File != "" {
+		b.WriteString(d.File)
+		if d.Line > 0 {
+			fmt.Fprintf(&b, "(%d,%d)", d.Line, d.Column)
+		}
+		b.WriteString(": ")
+	}
+	fmt.Fprintf(&b, "%v TS%d: %s", d.Category, d.Code, d.Message)
+	return b.String()
+}
+
+// DiagnosticsError is returned when the compiler reported one or more error diagnostics and the
+// caller asked for errors to fail the operation (see WithFailOnDiagnosticErrors).
+type DiagnosticsError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *DiagnosticsError) Error() string {
+	var errs []string
+	for _, d := range e.Diagnostics {
+		if d.Category == DiagnosticCategoryError {
+			errs = append(errs, d.String())
+		}
+	}
+	return fmt.Sprintf("typescript reported %d error(s): %s", len(errs), strings.Join(errs, "; "))
+}
+
+// hasErrors returns true if any of the provided diagnostics is an error.
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Category == DiagnosticCategoryError {
+			return true
+		}
+	}
+	return false
+}
+
+// diagnosticsConverter is a javascript function expression that converts a list of ts.Diagnostic
+// objects into plain objects that can be JSON-serialized and decoded into a []Diagnostic.
+const diagnosticsConverter = `function (diagnostics) {
+	function chain(m) {
+		return { message: m.messageText, category: m.category, code: m.code, next: [].concat(m.next || []).map(chain) };
+	}
+	return (diagnostics || []).map(function (d) {
+		var out = {
+			code: d.code,
+			category: d.category,
+			message: ts.flattenDiagnosticMessageText(d.messageText, "\n"),
+			start: d.start,
+			length: d.length
+		};
+		if (typeof d.messageText !== "string") {
+			out.chain = chain(d.messageText);
+		}
+		if (d.file) {
+			out.file = d.file.fileName;
+			if (d.start !== undefined) {
+				var lc = ts.getLineAndCharacterOfPosition(d.file, d.start);
+				out.line = lc.line + 1;
+				out.column = lc.character + 1;
+			}
+		}
+		return out;
+	});
+}`
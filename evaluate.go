@@ -2,13 +2,13 @@ package typescript
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"strings"
 
 	"github.com/clarkmcc/go-typescript/packages"
-	_ "github.com/clarkmcc/go-typescript/versions/v4.9.3"
 	"github.com/dop251/goja"
 )
 
@@ -19,7 +19,9 @@ type EvaluateConfig struct {
 	EvaluateBefore []io.Reader
 	// ScriptHooks are called after transpiling (if applicable) with the script that will be evaluated immediately
 	// before evaluation. If an error is returned from any of these functions, the evaluation process is aborted.
-	// The script hook can make modifications and return them to the script if necessary.
+	// The script hook can make modifications and return them to the script if necessary. The source map of the
+	// transpiled script doesn't describe a modified script, so the positions in a *ScriptError aren't mapped
+	// back to the typescript source when a hook modifies the script.
 	ScriptHooks []func(string) (string, error)
 	// ScriptPreTranspileHooks are called before transpiling (if applicable) with the script that will be evaluated
 	ScriptPreTranspileHooks []func(string) (string, error)
@@ -29,6 +31,10 @@ type EvaluateConfig struct {
 	TranspileOptions []TranspileOptionFunc
 	// Runtime is the goja runtime used for script execution. If not specified, it defaults to an empty runtime
 	Runtime *goja.Runtime
+	// Modules are host provided modules that the script can import, keyed by module specifier.
+	Modules map[string]ModuleLoader
+	// JSX enables JSX support when set, see WithJSX.
+	JSX *JSXOptions
 }
 
 // ApplyDefaults applies defaults to the configuration and is called automatically before the config is used
@@ -97,6 +103,8 @@ func Evaluate(src io.Reader, opts ...EvaluateOptionFunc) (goja.Value, error) {
 }
 
 // EvaluateCtx evaluates the provided src using the specified options and returns the goja value result or an error.
+// If the script was transpiled and throws an exception, the error is a *ScriptError with the stack trace mapped back
+// to the typescript source.
 func EvaluateCtx(ctx context.Context, src io.Reader, opts ...EvaluateOptionFunc) (result goja.Value, err error) {
 	cfg := &EvaluateConfig{}
 	cfg.ApplyDefaults()
@@ -105,6 +113,12 @@ func EvaluateCtx(ctx context.Context, src io.Reader, opts ...EvaluateOptionFunc)
 	}
 	done := startInterruptable(ctx, cfg.Runtime)
 	defer close(done)
+	if cfg.JSX != nil {
+		err = cfg.JSX.install(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("installing jsx runtime: %w", err)
+		}
+	}
 	if cfg.HasEvaluateBefore() {
 		for _, s := range cfg.EvaluateBefore {
 			b, err := ioutil.ReadAll(s)
@@ -118,11 +132,19 @@ func EvaluateCtx(ctx context.Context, src io.Reader, opts ...EvaluateOptionFunc)
 		}
 	}
 
+	if len(cfg.Modules) > 0 {
+		err = installModules(cfg.Runtime, cfg.Modules)
+		if err != nil {
+			return nil, fmt.Errorf("installing modules: %w", err)
+		}
+	}
+
 	b, err := ioutil.ReadAll(src)
 	if err != nil {
 		return nil, fmt.Errorf("reading src: %w", err)
 	}
 	script := string(b)
+	var sourceMap *SourceMap
 	if cfg.Transpile {
 		// This is needed in case the script being transpiled imports other modules. Check if it already exists in case
 		// the caller has their own implementation and use of the global exports object.
@@ -136,29 +158,53 @@ func EvaluateCtx(ctx context.Context, src io.Reader, opts ...EvaluateOptionFunc)
 			// We handle our own runtime with our own cancellation
 			WithRuntime(cfg.Runtime),
 			WithPreventCancellation(),
+			WithFileName(readerName(src)),
 		}
 		opts = append(opts, cfg.TranspileOptions...)
+		if cfg.JSX != nil {
+			opts = append(opts, cfg.JSX.transpileOption())
+		}
+		// We need a separate source map so that we can map runtime exceptions back to the typescript source,
+		// unless the caller asked for an inline source map, which goja uses to do this mapping itself.
+		var inline bool
+		opts = append(opts, func(config *Config) {
+			inline = config.inlineSourceMap()
+			config.InlineSourceMap = inline
+			config.SourceMap = !inline
+		})
 		for _, h := range cfg.ScriptPreTranspileHooks {
 			script, err = h(script)
 			if err != nil {
 				return nil, fmt.Errorf("running script pre-transpile hook: %w", err)
 			}
 		}
-		script, err = TranspileCtx(ctx, strings.NewReader(script), opts...)
+		transpiled, err := TranspileModuleCtx(ctx, strings.NewReader(script), opts...)
 		if err != nil {
 			return nil, fmt.Errorf("transpiling script: %w", err)
 		}
+		script = transpiled.Code
+		if !inline {
+			sourceMap = transpiled.SourceMap
+		}
 	}
 	for _, h := range cfg.ScriptHooks {
-		script, err = h(script)
+		hooked, err := h(script)
 		if err != nil {
 			return nil, fmt.Errorf("running script hook: %w", err)
 		}
+		// The source map doesn't describe the modified script
+		if hooked != script {
+			sourceMap = nil
+		}
+		script = hooked
 	}
 	result, err = cfg.Runtime.RunString(script)
 	if err != nil {
+		var exception *goja.Exception
 		if strings.Contains(err.Error(), "context halt") {
 			err = context.Canceled
+		} else if sourceMap != nil && errors.As(err, &exception) {
+			err = newScriptError(exception, sourceMap)
 		}
 	}
 	return
@@ -27,7 +27,7 @@ var (
 
 func TestEvaluateCtx(t *testing.T) {
 	registry := versions.NewRegistry()
-	registry.Register("v4.9.3", v4_9_3.Source)
+	v4_9_3.Register(registry)
 
 	// This test hits a lot of things:
 	//  #1 - We test that we can load the almond AMD module loader
@@ -103,6 +103,67 @@ func TestEvaluateCtx(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("exception mapped to typescript source", func(t *testing.T) {
+		script := strings.Join([]string{
+			"interface Options { message: string }",
+			"function fail(opts: Options): never {",
+			"  throw new Error(opts.message);",
+			"}",
+			"fail({ message: 'boom' });",
+		}, "\n")
+		_, err := Evaluate(strings.NewReader(script),
+			WithTranspile(),
+			WithTranspileOptions(WithRegistry(registry), WithVersion("v4.9.3")))
+		var scriptErr *ScriptError
+		require.True(t, errors.As(err, &scriptErr))
+		var exception *goja.Exception
+		require.True(t, errors.As(err, &exception))
+		require.Len(t, scriptErr.Frames, 2)
+
+		require.Equal(t, "fail", scriptErr.Frames[0].FuncName)
+		require.True(t, scriptErr.Frames[0].Mapped)
+		require.Equal(t, 2, scriptErr.Frames[0].Generated.Line)
+		require.Equal(t, "module.ts", scriptErr.Frames[0].Original.Source)
+		require.Equal(t, 3, scriptErr.Frames[0].Original.Line)
+
+		require.True(t, scriptErr.Frames[1].Mapped)
+		require.Equal(t, 4, scriptErr.Frames[1].Generated.Line)
+		require.Equal(t, 5, scriptErr.Frames[1].Original.Line)
+		require.Equal(t, "Error: boom at fail (module.ts:3:9)", err.Error())
+	})
+
+	t.Run("inline source map", func(t *testing.T) {
+		script := "function fail(): never {\n  throw new Error('boom');\n}\nfail();"
+		for name, opt := range map[string]TranspileOptionFunc{
+			"option":         WithInlineSourceMap(),
+			"compile option": WithCompileOptions(map[string]interface{}{"inlineSourceMap": true}),
+		} {
+			t.Run(name, func(t *testing.T) {
+				// The compiler would reject sourceMap with inlineSourceMap, which fails on diagnostic errors
+				_, err := Evaluate(strings.NewReader(script),
+					WithTranspile(),
+					WithTranspileOptions(WithRegistry(registry), WithVersion("v4.9.3"), opt, WithFailOnDiagnosticErrors()))
+				var exception *goja.Exception
+				require.True(t, errors.As(err, &exception))
+				var scriptErr *ScriptError
+				require.False(t, errors.As(err, &scriptErr), "goja maps exceptions with the inline source map")
+			})
+		}
+	})
+
+	t.Run("script hook modifies transpiled script", func(t *testing.T) {
+		_, err := Evaluate(strings.NewReader("function fail(): never {\n  throw new Error('boom');\n}\nfail();"),
+			WithTranspile(),
+			WithTranspileOptions(WithRegistry(registry), WithVersion("v4.9.3")),
+			WithScriptHook(func(s string) (string, error) {
+				return "\n\n" + s, nil
+			}))
+		var exception *goja.Exception
+		require.True(t, errors.As(err, &exception))
+		var scriptErr *ScriptError
+		require.False(t, errors.As(err, &scriptErr), "the source map doesn't describe the modified script")
+	})
+
 	t.Run("pre-transpile hook", func(t *testing.T) {
 		s1 := "let a: number = 10"
 		_, err := Evaluate(strings.NewReader(s1),
@@ -14,7 +14,7 @@ var module string
 
 func ExampleTypescriptAMDModule() {
 	registry := versions.NewRegistry()
-	registry.Register("v4.9.3", v4_9_3.Source)
+	v4_9_3.Register(registry)
 
 	result, err := typescript.Evaluate(strings.NewReader(`import { multiply } from 'myModule'; multiply(5, 5)`),
 		typescript.WithTranspile(),
@@ -18,7 +18,7 @@ func ExampleContext() {
 	cancel()
 
 	registry := versions.NewRegistry()
-	registry.Register("v4.9.3", v4_9_3.Source)
+	v4_9_3.Register(registry)
 
 	_, err := typescript.TranspileCtx(ctx,
 		strings.NewReader(script3),
@@ -14,7 +14,7 @@ var script2 string
 
 func ExampleTypescriptEvaluate() {
 	registry := versions.NewRegistry()
-	registry.Register("v4.9.3", v4_9_3.Source)
+	v4_9_3.Register(registry)
 
 	// Transpile the typescript and return evaluated result
 	result, err := typescript.Evaluate(strings.NewReader(script2), typescript.WithTranspile(), typescript.WithTranspileOptions(
@@ -27,7 +27,7 @@ me.greet();`
 
 func ExampleTranspile() {
 	registry := versions.NewRegistry()
-	registry.Register("v4.9.3", v4_9_3.Source)
+	v4_9_3.Register(registry)
 
 	// Only transpile the typescript and return transpiled Javascript, don't evaluate
 	transpiled, err := typescript.TranspileString(script1, typescript.WithRegistry(registry), typescript.WithVersion("v4.9.3"))
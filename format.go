@@ -0,0 +1,146 @@
+package typescript
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// SemicolonPreference decides how the formatter treats optional semicolons at the end of statements.
+type SemicolonPreference string
+
+const (
+	// SemicolonsIgnore leaves semicolons as they are.
+	SemicolonsIgnore SemicolonPreference = "ignore"
+	// SemicolonsInsert adds missing semicolons.
+	SemicolonsInsert SemicolonPreference = "insert"
+	// SemicolonsRemove removes semicolons that aren't needed.
+	SemicolonsRemove SemicolonPreference = "remove"
+)
+
+// FormatOptions are the settings used by the typescript formatter. The zero value formats with four space
+// indentation and leaves semicolons as they are.
+type FormatOptions struct {
+	// IndentSize is the number of columns in each level of indentation, which defaults to 4.
+	IndentSize int
+	// TabSize is the number of columns in a tab, which defaults to the indent size.
+	TabSize int
+	// UseTabs indents with tabs instead of spaces.
+	UseTabs bool
+	// NewLine is the newline sequence used in inserted text, which defaults to "\n".
+	NewLine string
+	// Semicolons defaults to SemicolonsIgnore.
+	Semicolons SemicolonPreference
+	// BraceOnNewLineForFunctions places the opening brace of functions and classes on a new line.
+	BraceOnNewLineForFunctions bool
+	// BraceOnNewLineForControlBlocks places the opening brace of blocks such as if and for statements on a
+	// new line.
+	BraceOnNewLineForControlBlocks bool
+}
+
+// settings returns the options as a ts.FormatCodeSettings object.
+func (o FormatOptions) settings() map[string]interface{} {
+	indentSize := o.IndentSize
+	if indentSize <= 0 {
+		indentSize = 4
+	}
+	tabSize := o.TabSize
+	if tabSize <= 0 {
+		tabSize = indentSize
+	}
+	return map[string]interface{}{
+		"indentSize":                          indentSize,
+		"tabSize":                             tabSize,
+		"convertTabsToSpaces":                 !o.UseTabs,
+		"newLineCharacter":                    defaultString(o.NewLine, "\n"),
+		"semicolons":                          defaultString(string(o.Semicolons), string(SemicolonsIgnore)),
+		"placeOpenBraceOnNewLineForFunctions": o.BraceOnNewLineForFunctions,
+		"placeOpenBraceOnNewLineForControlBlocks": o.BraceOnNewLineForControlBlocks,
+	}
+}
+
+// TextEdit replaces the text in a span, in UTF-16 offsets, with new text.
+type TextEdit struct {
+	TextSpan TextSpan `json:"textSpan"`
+	NewText  string   `json:"newText"`
+}
+
+// FormatResult is the result of formatting a script.
+type FormatResult struct {
+	// Text is the formatted script.
+	Text string
+	// Edits are the edits that turn the original script into the formatted script, ordered by position.
+	Edits []TextEdit
+}
+
+// Format calls FormatCtx using the default background context.
+func Format(src io.Reader, options FormatOptions, opts ...TranspileOptionFunc) (*FormatResult, error) {
+	return FormatCtx(context.Background(), src, options, opts...)
+}
+
+// FormatString formats the provided typescript string.
+func FormatString(src string, options FormatOptions, opts ...TranspileOptionFunc) (*FormatResult, error) {
+	return FormatCtx(context.Background(), strings.NewReader(src), options, opts...)
+}
+
+// FormatCtx formats the script read from src with the typescript formatter, using a LanguageService with
+// the script as its only file. The file name decides how the script is parsed, for example .tsx enables
+// JSX, and defaults to module.ts. The context only applies to loading the compiler.
+func FormatCtx(ctx context.Context, src io.Reader, options FormatOptions, opts ...TranspileOptionFunc) (*FormatResult, error) {
+	b, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("reading src: %w", err)
+	}
+	service, err := NewLanguageServiceCtx(ctx, append([]TranspileOptionFunc{WithFileName(readerName(src))}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	fileName := defaultString(service.cfg.FileName, "module.ts")
+	text := string(b)
+	service.SetFile(fileName, text)
+	edits, err := service.FormattingEdits(fileName, options)
+	if err != nil {
+		return nil, err
+	}
+	return &FormatResult{Text: ApplyTextEdits(text, edits), Edits: edits}, nil
+}
+
+// ApplyTextEdits returns the text with the edits applied. The edits must not overlap, and their spans are
+// relative to the original text.
+func ApplyTextEdits(text string, edits []TextEdit) string {
+	if len(edits) == 0 {
+		return text
+	}
+	sorted := make([]TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TextSpan.Start < sorted[j].TextSpan.Start
+	})
+	units := utf16.Encode([]rune(text))
+	var out []uint16
+	last := 0
+	for _, edit := range sorted {
+		start := clamp(edit.TextSpan.Start, last, len(units))
+		end := clamp(edit.TextSpan.Start+edit.TextSpan.Length, start, len(units))
+		out = append(out, units[last:start]...)
+		out = append(out, utf16.Encode([]rune(edit.NewText))...)
+		last = end
+	}
+	out = append(out, units[last:]...)
+	return string(utf16.Decode(out))
+}
+
+// clamp returns n limited to the range [min, max].
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
@@ -0,0 +1,70 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	opts := []TranspileOptionFunc{WithRegistry(registry), WithVersion("v4.9.3")}
+
+	script := "function  greet(name:string){\nif(name){\nreturn 'hi '+name\n}\nreturn 'héllo 👋'\n}"
+
+	t.Run("defaults", func(t *testing.T) {
+		result, err := FormatString(script, FormatOptions{}, opts...)
+		require.NoError(t, err)
+		require.Equal(t, strings.Join([]string{
+			"function greet(name: string) {",
+			"    if (name) {",
+			"        return 'hi ' + name",
+			"    }",
+			"    return 'héllo 👋'",
+			"}",
+		}, "\n"), result.Text)
+		require.NotEmpty(t, result.Edits)
+		require.Equal(t, result.Text, ApplyTextEdits(script, result.Edits))
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		result, err := FormatString(script, FormatOptions{
+			IndentSize:                     2,
+			Semicolons:                     SemicolonsInsert,
+			BraceOnNewLineForFunctions:     true,
+			BraceOnNewLineForControlBlocks: true,
+		}, opts...)
+		require.NoError(t, err)
+		require.Equal(t, strings.Join([]string{
+			"function greet(name: string)",
+			"{",
+			"  if (name)",
+			"  {",
+			"    return 'hi ' + name;",
+			"  }",
+			"  return 'héllo 👋';",
+			"}",
+		}, "\n"), result.Text)
+	})
+
+	t.Run("tabs", func(t *testing.T) {
+		result, err := FormatString("class A {\nm() {\nreturn 1;\n}\n}", FormatOptions{UseTabs: true, Semicolons: SemicolonsRemove}, opts...)
+		require.NoError(t, err)
+		require.Equal(t, "class A {\n\tm() {\n\t\treturn 1\n\t}\n}", result.Text)
+	})
+}
+
+func TestApplyTextEdits(t *testing.T) {
+	// Spans are UTF-16 offsets, so the emoji counts as two units
+	text := "👋 a;b"
+	edits := []TextEdit{
+		{TextSpan: TextSpan{Start: 5, Length: 0}, NewText: " "},
+		{TextSpan: TextSpan{Start: 2, Length: 1}, NewText: ""},
+	}
+	require.Equal(t, "👋a; b", ApplyTextEdits(text, edits))
+	require.Equal(t, text, ApplyTextEdits(text, nil))
+}
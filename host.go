@@ -0,0 +1,167 @@
+package typescript
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	"github.com/dop251/goja"
+)
+
+// DefaultLibLocation is the directory the compiler host and the LanguageService report as the location of the
+// default lib.*.d.ts files. This mirrors the layout of an npm installation of typescript.
+const DefaultLibLocation = "/node_modules/typescript/lib"
+
+// fsHost exposes an fs.FS to the typescript compiler. Paths from the compiler are absolute and
+// rooted at "/", and are mapped to the equivalent unrooted path in the FS. Default lib files that
+// are not found in the FS are served from libs, if set. Declarations, keyed by their compiler path,
+// take precedence over the files in the FS.
+type fsHost struct {
+	runtime      *goja.Runtime
+	fsys         fs.FS
+	libs         fs.FS
+	declarations map[string]string
+}
+
+// newFSHost creates a host for fsys, serving the default lib files registered in the config's
+// registry for the config's typescript version, if any.
+func newFSHost(cfg *Config, fsys fs.FS) *fsHost {
+	h := &fsHost{runtime: cfg.Runtime, fsys: fsys, declarations: cfg.Declarations}
+	if r, ok := cfg.Registry.(versions.LibRegistry); ok {
+		h.libs, _ = r.Libs(cfg.TypescriptVersion)
+	}
+	return h
+}
+
+// object returns a javascript object with the file system functions needed by the compilerHost
+// javascript function.
+func (h *fsHost) object() *goja.Object {
+	o := h.runtime.NewObject()
+	_ = o.Set("readFile", h.readFile)
+	_ = o.Set("fileExists", h.fileExists)
+	_ = o.Set("directoryExists", h.directoryExists)
+	_ = o.Set("getDirectories", h.getDirectories)
+	_ = o.Set("getFileSystemEntries", h.getFileSystemEntries)
+	return o
+}
+
+func (h *fsHost) readFile(call goja.FunctionCall) goja.Value {
+	name := call.Argument(0).String()
+	if text, ok := h.declarations[compilerPath(name)]; ok {
+		return h.runtime.ToValue(text)
+	}
+	b, err := fs.ReadFile(h.fsys, fsPath(name))
+	if err != nil {
+		lib, ok := h.libPath(name)
+		if !ok {
+			return goja.Undefined()
+		}
+		b, err = fs.ReadFile(h.libs, lib)
+		if err != nil {
+			return goja.Undefined()
+		}
+	}
+	return h.runtime.ToValue(string(b))
+}
+
+func (h *fsHost) fileExists(name string) bool {
+	if _, ok := h.declarations[compilerPath(name)]; ok {
+		return true
+	}
+	info, err := fs.Stat(h.fsys, fsPath(name))
+	if err != nil {
+		lib, ok := h.libPath(name)
+		if !ok {
+			return false
+		}
+		info, err = fs.Stat(h.libs, lib)
+	}
+	return err == nil && !info.IsDir()
+}
+
+func (h *fsHost) directoryExists(name string) bool {
+	if h.libs != nil && compilerPath(name) == DefaultLibLocation {
+		return true
+	}
+	info, err := fs.Stat(h.fsys, fsPath(name))
+	return err == nil && info.IsDir()
+}
+
+// getFileSystemEntries returns the names of the files and directories in a directory, in the shape
+// expected by ts.matchFiles.
+func (h *fsHost) getFileSystemEntries(name string) *goja.Object {
+	var files, directories []interface{}
+	entries, _ := fs.ReadDir(h.fsys, fsPath(name))
+	for _, e := range entries {
+		if e.IsDir() {
+			directories = append(directories, e.Name())
+		} else {
+			files = append(files, e.Name())
+		}
+	}
+	o := h.runtime.NewObject()
+	_ = o.Set("files", h.runtime.NewArray(files...))
+	_ = o.Set("directories", h.runtime.NewArray(directories...))
+	return o
+}
+
+// libPath returns the path of the file in the libs file system if name is a file in the default
+// lib location and the host has libs.
+func (h *fsHost) libPath(name string) (string, bool) {
+	name = compilerPath(name)
+	if h.libs == nil || path.Dir(name) != DefaultLibLocation {
+		return "", false
+	}
+	return path.Base(name), true
+}
+
+func (h *fsHost) getDirectories(name string) []string {
+	entries, err := fs.ReadDir(h.fsys, fsPath(name))
+	if err != nil {
+		return []string{}
+	}
+	dirs := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	return dirs
+}
+
+// compilerPath converts a path in an fs.FS into an absolute compiler path.
+func compilerPath(name string) string {
+	return path.Clean("/" + name)
+}
+
+// fsPath converts an absolute compiler path into a path that is valid for an fs.FS.
+func fsPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// compilerHost is a javascript function expression that creates a ts.CompilerHost from the object
+// returned by fsHost.object. Emitted files are collected in the outputs object.
+const compilerHost = `function (host, outputs) {
+	return {
+		getSourceFile: function (fileName, languageVersion) {
+			var text = host.readFile(fileName);
+			return text === undefined ? undefined : ts.createSourceFile(fileName, text, languageVersion);
+		},
+		getDefaultLibLocation: function () { return "` + DefaultLibLocation + `"; },
+		getDefaultLibFileName: function (options) { return "` + DefaultLibLocation + `/" + ts.getDefaultLibFileName(options); },
+		writeFile: function (fileName, data) { outputs[fileName] = data; },
+		getCurrentDirectory: function () { return "/"; },
+		getDirectories: function (path) { return host.getDirectories(path); },
+		fileExists: function (fileName) { return host.fileExists(fileName); },
+		readFile: function (fileName) { return host.readFile(fileName); },
+		directoryExists: function (directoryName) { return host.directoryExists(directoryName); },
+		getCanonicalFileName: function (fileName) { return fileName; },
+		useCaseSensitiveFileNames: function () { return true; },
+		getNewLine: function () { return "\n"; }
+	};
+}`
@@ -24,3 +24,24 @@ func startInterruptable(ctx context.Context, vm *goja.Runtime) chan struct{} {
 	<-started
 	return done
 }
+
+// startResettableInterruptable is like startInterruptable but for runtimes that are reused after the
+// context is done. The returned function stops handling cancellation, waits for the handler to exit
+// and clears any interrupt so that the runtime can be used again.
+func startResettableInterruptable(ctx context.Context, vm *goja.Runtime) func() {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			vm.Interrupt("context halt")
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+		vm.ClearInterrupt()
+	}
+}
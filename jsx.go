@@ -0,0 +1,137 @@
+package typescript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/clarkmcc/go-typescript/packages"
+	"github.com/dop251/goja"
+)
+
+// JSXOptions determine how JSX is transpiled and which runtime is used to create elements.
+type JSXOptions struct {
+	// Factory is the function used to create elements with the classic runtime. Defaults to h, which is
+	// declared by the bundled JSX runtime.
+	Factory string
+	// FragmentFactory is the component used for fragments with the classic runtime. Defaults to Fragment,
+	// which is declared by the bundled JSX runtime.
+	FragmentFactory string
+	// Automatic uses the automatic runtime, where the transpiled script imports the jsx functions from the
+	// ImportSource + "/jsx-runtime" module rather than calling the Factory. Requires typescript 4.1 or later.
+	Automatic bool
+	// ImportSource is the module the automatic runtime imports the jsx functions from. Defaults to
+	// go-typescript, which resolves to the bundled JSX runtime.
+	ImportSource string
+}
+
+const defaultJSXImportSource = "go-typescript"
+
+// WithJSX transpiles the script with JSX support and declares the bundled JSX runtime in the runtime before
+// any scripts are evaluated. Unless a file name is provided, the script is transpiled as module.tsx.
+func WithJSX(options JSXOptions) EvaluateOptionFunc {
+	return func(cfg *EvaluateConfig) {
+		cfg.Transpile = true
+		cfg.JSX = &options
+	}
+}
+
+// install evaluates the bundled JSX runtime and, when using the automatic runtime, declares the modules that
+// the transpiled script imports the jsx functions from.
+func (o *JSXOptions) install(cfg *EvaluateConfig) error {
+	_, err := cfg.Runtime.RunString(packages.JSX)
+	if err != nil {
+		return err
+	}
+	if !o.Automatic || o.ImportSource != "" && o.ImportSource != defaultJSXImportSource {
+		return nil
+	}
+	loader := func(runtime *goja.Runtime) (goja.Value, error) {
+		return runtime.Get("__jsxRuntime"), nil
+	}
+	WithModule(defaultJSXImportSource+"/jsx-runtime", loader)(cfg)
+	WithModule(defaultJSXImportSource+"/jsx-dev-runtime", loader)(cfg)
+	return nil
+}
+
+// transpileOption returns the option that configures the transpiler for JSX. It must be applied after any
+// other compile options as it modifies them.
+func (o *JSXOptions) transpileOption() TranspileOptionFunc {
+	return func(config *Config) {
+		options := make(map[string]interface{}, len(config.CompileOptions)+3)
+		for k, v := range config.CompileOptions {
+			options[k] = v
+		}
+		if o.Automatic {
+			options["jsx"] = "react-jsx"
+			options["jsxImportSource"] = defaultString(o.ImportSource, defaultJSXImportSource)
+			if _, ok := options["module"]; !ok {
+				options["module"] = "commonjs"
+			}
+		} else {
+			options["jsx"] = "react"
+			options["jsxFactory"] = defaultString(o.Factory, "h")
+			options["jsxFragmentFactory"] = defaultString(o.FragmentFactory, "Fragment")
+		}
+		config.CompileOptions = options
+		if config.FileName == "" {
+			config.FileName = "module.tsx"
+		}
+	}
+}
+
+// RenderToString evaluates the TSX module read from src and renders the component that is the module's default
+// export to an HTML string, using the bundled JSX runtime. The props are converted to a javascript object by
+// encoding them as JSON and passed to the component. All text and attribute values are HTML escaped. The
+// classic JSX runtime is used unless the options include WithJSX.
+func RenderToString(ctx context.Context, src io.Reader, props interface{}, opts ...EvaluateOptionFunc) (string, error) {
+	cfg := &EvaluateConfig{}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	runtime := cfg.Runtime
+	if runtime == nil {
+		runtime = goja.New()
+		opts = append(opts, WithEvaluationRuntime(runtime))
+	}
+	if cfg.JSX == nil {
+		opts = append(opts, WithJSX(JSXOptions{}))
+	}
+	_, err := EvaluateCtx(ctx, src, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	exports := runtime.Get("exports")
+	if exports == nil || goja.IsUndefined(exports) || goja.IsNull(exports) {
+		return "", fmt.Errorf("script does not have any exports")
+	}
+	component := exports.ToObject(runtime).Get("default")
+	if _, ok := goja.AssertFunction(component); !ok {
+		return "", fmt.Errorf("script does not have a default export that is a component")
+	}
+	b, err := json.Marshal(props)
+	if err != nil {
+		return "", fmt.Errorf("marshalling props: %w", err)
+	}
+	value, err := runtime.RunString(`(function (component, props) {
+	return renderToString(h(component, JSON.parse(props)));
+})`)
+	if err != nil {
+		return "", fmt.Errorf("creating renderer: %w", err)
+	}
+	render, _ := goja.AssertFunction(value)
+	html, err := render(goja.Undefined(), component, runtime.ToValue(string(b)))
+	if err != nil {
+		return "", fmt.Errorf("rendering component: %w", err)
+	}
+	return html.String(), nil
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
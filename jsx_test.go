@@ -0,0 +1,88 @@
+package typescript
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderToString(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	transpileOpts := WithTranspileOptions(WithRegistry(registry), WithVersion("v4.9.3"))
+
+	component := strings.Join([]string{
+		"interface Props { title: string; items: string[] }",
+		"export default function List({ title, items }: Props) {",
+		"  return <>",
+		"    <h1 className=\"title\">{title}</h1>",
+		"    <br />",
+		"    <ul>{items.map(item => <li key={item}>{item}</li>)}</ul>",
+		"  </>;",
+		"}",
+	}, "\n")
+	expected := `<h1 class="title">Fish &amp; Chips</h1><br><ul><li>&lt;b&gt;</li><li>two</li></ul>`
+
+	t.Run("classic runtime", func(t *testing.T) {
+		html, err := RenderToString(context.Background(), strings.NewReader(component), map[string]interface{}{
+			"title": "Fish & Chips",
+			"items": []string{"<b>", "two"},
+		}, transpileOpts)
+		require.NoError(t, err)
+		require.Equal(t, expected, html)
+	})
+
+	t.Run("automatic runtime", func(t *testing.T) {
+		html, err := RenderToString(context.Background(), strings.NewReader(component), map[string]interface{}{
+			"title": "Fish & Chips",
+			"items": []string{"<b>", "two"},
+		}, transpileOpts, WithJSX(JSXOptions{Automatic: true}))
+		require.NoError(t, err)
+		require.Equal(t, expected, html)
+	})
+
+	t.Run("invalid attribute names", func(t *testing.T) {
+		html, err := RenderToString(context.Background(), strings.NewReader("export default function A(props: any) { return <div {...props.attrs} />; }"), map[string]interface{}{
+			"attrs": map[string]interface{}{
+				"data-ok":                "1",
+				"onmouseover=alert(1) x": "y",
+				"a\"b":                   "c",
+				"x>":                     "d",
+				"":                       "e",
+			},
+		}, transpileOpts)
+		require.NoError(t, err)
+		require.Equal(t, `<div data-ok="1"></div>`, html)
+	})
+
+	t.Run("no default export", func(t *testing.T) {
+		_, err := RenderToString(context.Background(), strings.NewReader("export const a = <div />;"), nil, transpileOpts)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "default export")
+	})
+}
+
+func TestWithModule(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+
+	result, err := Evaluate(strings.NewReader("import { add } from 'host:math'; add(2, 3)"),
+		WithTranspile(),
+		WithTranspileOptions(WithRegistry(registry), WithVersion("v4.9.3")),
+		WithModule("host:math", func(runtime *goja.Runtime) (goja.Value, error) {
+			exports := runtime.NewObject()
+			err := exports.Set("add", func(a, b int) int { return a + b })
+			return exports, err
+		}))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), result.ToInteger())
+
+	_, err = Evaluate(strings.NewReader("require('missing')"), WithModule("host:math", nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot find module 'missing'")
+}
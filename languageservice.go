@@ -0,0 +1,582 @@
+package typescript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	"github.com/dop251/goja"
+)
+
+// TextSpan is a range of text in a file. Offsets are in UTF-16 code units, as used by the compiler, which
+// are the same as byte offsets for ASCII text.
+type TextSpan struct {
+	Start  int `json:"start"`
+	Length int `json:"length"`
+}
+
+// Location is a span of text in a file, along with the 1-based line and column of its start and end.
+type Location struct {
+	FileName  string   `json:"fileName"`
+	TextSpan  TextSpan `json:"textSpan"`
+	Line      int      `json:"line"`
+	Column    int      `json:"column"`
+	EndLine   int      `json:"endLine"`
+	EndColumn int      `json:"endColumn"`
+}
+
+// CompletionInfo is the list of completions available at a position in a file.
+type CompletionInfo struct {
+	IsMemberCompletion      bool              `json:"isMemberCompletion"`
+	IsNewIdentifierLocation bool              `json:"isNewIdentifierLocation"`
+	Entries                 []CompletionEntry `json:"entries"`
+}
+
+// CompletionEntry is a single completion.
+type CompletionEntry struct {
+	Name string `json:"name"`
+	// Kind is the kind of symbol, such as "function", "var" or "property".
+	Kind          string `json:"kind"`
+	KindModifiers string `json:"kindModifiers"`
+	// SortText is used to order the entries, entries should be sorted by this rather than by name.
+	SortText string `json:"sortText"`
+	// InsertText is the text to insert if it differs from the name.
+	InsertText string `json:"insertText,omitempty"`
+	// ReplacementSpan is the span of text that the completion replaces, if it isn't the word at the position.
+	ReplacementSpan *TextSpan `json:"replacementSpan,omitempty"`
+}
+
+// QuickInfo is the hover information for the symbol at a position in a file.
+type QuickInfo struct {
+	Kind          string   `json:"kind"`
+	KindModifiers string   `json:"kindModifiers"`
+	TextSpan      TextSpan `json:"textSpan"`
+	// Display is the signature of the symbol, for example "function add(a: number, b: number): number".
+	Display string `json:"display"`
+	// Documentation is the text of the symbol's doc comment.
+	Documentation string     `json:"documentation"`
+	Tags          []JSDocTag `json:"tags"`
+}
+
+// JSDocTag is a tag from a doc comment, such as @param or @deprecated.
+type JSDocTag struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// DefinitionInfo is the location where a symbol is defined.
+type DefinitionInfo struct {
+	Location
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	ContainerKind string `json:"containerKind"`
+	ContainerName string `json:"containerName"`
+}
+
+// ReferenceEntry is a location where a symbol is referenced.
+type ReferenceEntry struct {
+	Location
+	IsWriteAccess bool `json:"isWriteAccess"`
+	IsDefinition  bool `json:"isDefinition"`
+}
+
+// SignatureHelp describes the signatures of the call that a position in a file is an argument of.
+type SignatureHelp struct {
+	Items []SignatureHelpItem `json:"items"`
+	// ApplicableSpan is the span of the call's arguments.
+	ApplicableSpan    TextSpan `json:"applicableSpan"`
+	SelectedItemIndex int      `json:"selectedItemIndex"`
+	ArgumentIndex     int      `json:"argumentIndex"`
+	ArgumentCount     int      `json:"argumentCount"`
+}
+
+// SignatureHelpItem is a single signature, such as an overload of a function.
+type SignatureHelpItem struct {
+	// Label is the full signature, for example "add(a: number, b: number): number".
+	Label         string                   `json:"label"`
+	Documentation string                   `json:"documentation"`
+	Parameters    []SignatureHelpParameter `json:"parameters"`
+	IsVariadic    bool                     `json:"isVariadic"`
+}
+
+// SignatureHelpParameter is a parameter of a signature.
+type SignatureHelpParameter struct {
+	Name string `json:"name"`
+	// Label is the parameter as it appears in the signature's label, for example "a: number".
+	Label         string `json:"label"`
+	Documentation string `json:"documentation"`
+	IsOptional    bool   `json:"isOptional"`
+}
+
+// LanguageService provides editor features, such as completions and hover information, for a mutable set of
+// in-memory files using the typescript language service. File names are absolute and rooted at "/", relative
+// names are resolved against "/". All positions are offsets in UTF-16 code units, see TextSpan.
+//
+// The default lib files are served from the lib files registered for the typescript version if the registry
+// is a versions.LibRegistry. Use the "noLib" compile option if they aren't available. A LanguageService is safe
+// for concurrent use, but calls are serialized because the underlying goja runtime is not goroutine-safe.
+type LanguageService struct {
+	lock  sync.Mutex
+	cfg   *Config
+	files map[string]*languageServiceFile
+	// version is incremented whenever a file changes, so that a file that is removed and added again never
+	// reuses the version of a stale copy held by the document registry.
+	version int
+	libs    fs.FS
+	request goja.Callable
+}
+
+type languageServiceFile struct {
+	text    string
+	version int
+}
+
+// NewLanguageService calls NewLanguageServiceCtx using the default background context.
+func NewLanguageService(opts ...TranspileOptionFunc) (*LanguageService, error) {
+	return NewLanguageServiceCtx(context.Background(), opts...)
+}
+
+// NewLanguageServiceCtx creates a LanguageService and loads the typescript compiler into its runtime. The only
+// files are any declarations added with WithDeclarations. The compile options from the provided options are
+// used for all files. The context only applies to loading the compiler.
+func NewLanguageServiceCtx(ctx context.Context, opts ...TranspileOptionFunc) (*LanguageService, error) {
+	cfg, done, err := newCompilerConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	done()
+	cfg.Runtime.ClearInterrupt()
+	err = checkCompilerOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &LanguageService{cfg: cfg, files: make(map[string]*languageServiceFile)}
+	for name, text := range cfg.Declarations {
+		s.SetFile(name, text)
+	}
+	if r, ok := cfg.Registry.(versions.LibRegistry); ok {
+		s.libs, _ = r.Libs(cfg.TypescriptVersion)
+	}
+
+	value, err := cfg.Runtime.RunString("(" + languageService + ")")
+	if err != nil {
+		return nil, fmt.Errorf("creating language service: %w", err)
+	}
+	create, ok := goja.AssertFunction(value)
+	if !ok {
+		return nil, fmt.Errorf("language service is not a function")
+	}
+	optionBytes, err := json.Marshal(cfg.compilerOptions())
+	if err != nil {
+		return nil, fmt.Errorf("marshalling compile options: %w", err)
+	}
+	value, err = create(goja.Undefined(), s.hostObject(), cfg.Runtime.ToValue(string(optionBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("creating language service: %w", err)
+	}
+	s.request, ok = goja.AssertFunction(value)
+	if !ok {
+		return nil, fmt.Errorf("language service request handler is not a function")
+	}
+	return s, nil
+}
+
+// Version returns the typescript version tag loaded into the language service's runtime.
+func (s *LanguageService) Version() string {
+	return s.cfg.TypescriptVersion
+}
+
+// SetFile adds a file to the language service, or replaces the text of an existing file.
+func (s *LanguageService) SetFile(name, text string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	name = compilerPath(name)
+	if f, ok := s.files[name]; ok && f.text == text {
+		return
+	}
+	s.version++
+	s.files[name] = &languageServiceFile{text: text, version: s.version}
+}
+
+// RemoveFile removes a file from the language service.
+func (s *LanguageService) RemoveFile(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.files, compilerPath(name))
+}
+
+// File returns the text of a file in the language service.
+func (s *LanguageService) File(name string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.files[compilerPath(name)]
+	if !ok {
+		return "", false
+	}
+	return f.text, true
+}
+
+// Files returns the names of the files in the language service, in sorted order.
+func (s *LanguageService) Files() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.fileNames()
+}
+
+// Completions calls CompletionsCtx using the default background context.
+func (s *LanguageService) Completions(name string, offset int) (*CompletionInfo, error) {
+	return s.CompletionsCtx(context.Background(), name, offset)
+}
+
+// CompletionsCtx returns the completions available at the offset in the file, or nil if there are none.
+func (s *LanguageService) CompletionsCtx(ctx context.Context, name string, offset int) (*CompletionInfo, error) {
+	var info *CompletionInfo
+	return info, s.call(ctx, "completions", name, offset, &info)
+}
+
+// QuickInfo calls QuickInfoCtx using the default background context.
+func (s *LanguageService) QuickInfo(name string, offset int) (*QuickInfo, error) {
+	return s.QuickInfoCtx(context.Background(), name, offset)
+}
+
+// QuickInfoCtx returns the hover information for the symbol at the offset in the file, or nil if there is
+// no symbol at the offset.
+func (s *LanguageService) QuickInfoCtx(ctx context.Context, name string, offset int) (*QuickInfo, error) {
+	var info *QuickInfo
+	return info, s.call(ctx, "quickInfo", name, offset, &info)
+}
+
+// Definition calls DefinitionCtx using the default background context.
+func (s *LanguageService) Definition(name string, offset int) ([]DefinitionInfo, error) {
+	return s.DefinitionCtx(context.Background(), name, offset)
+}
+
+// DefinitionCtx returns the locations where the symbol at the offset in the file is defined.
+func (s *LanguageService) DefinitionCtx(ctx context.Context, name string, offset int) ([]DefinitionInfo, error) {
+	var definitions []DefinitionInfo
+	return definitions, s.call(ctx, "definition", name, offset, &definitions)
+}
+
+// SignatureHelp calls SignatureHelpCtx using the default background context.
+func (s *LanguageService) SignatureHelp(name string, offset int) (*SignatureHelp, error) {
+	return s.SignatureHelpCtx(context.Background(), name, offset)
+}
+
+// SignatureHelpCtx returns the signatures of the call that the offset in the file is an argument of, or nil
+// if the offset isn't within the arguments of a call.
+func (s *LanguageService) SignatureHelpCtx(ctx context.Context, name string, offset int) (*SignatureHelp, error) {
+	var help *SignatureHelp
+	return help, s.call(ctx, "signatureHelp", name, offset, &help)
+}
+
+// References calls ReferencesCtx using the default background context.
+func (s *LanguageService) References(name string, offset int) ([]ReferenceEntry, error) {
+	return s.ReferencesCtx(context.Background(), name, offset)
+}
+
+// ReferencesCtx returns the locations where the symbol at the offset in the file is referenced, including
+// its definitions.
+func (s *LanguageService) ReferencesCtx(ctx context.Context, name string, offset int) ([]ReferenceEntry, error) {
+	var references []ReferenceEntry
+	return references, s.call(ctx, "references", name, offset, &references)
+}
+
+// Diagnostics calls DiagnosticsCtx using the default background context.
+func (s *LanguageService) Diagnostics(name string) ([]Diagnostic, error) {
+	return s.DiagnosticsCtx(context.Background(), name)
+}
+
+// DiagnosticsCtx returns the syntactic and semantic diagnostics for the file.
+func (s *LanguageService) DiagnosticsCtx(ctx context.Context, name string) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	return diagnostics, s.call(ctx, "diagnostics", name, 0, &diagnostics)
+}
+
+// FormattingEdits calls FormattingEditsCtx using the default background context.
+func (s *LanguageService) FormattingEdits(name string, options FormatOptions) ([]TextEdit, error) {
+	return s.FormattingEditsCtx(context.Background(), name, options)
+}
+
+// FormattingEditsCtx returns the edits that format the whole file using the provided options. The edits are
+// ordered by position and don't overlap.
+func (s *LanguageService) FormattingEditsCtx(ctx context.Context, name string, options FormatOptions) ([]TextEdit, error) {
+	var edits []TextEdit
+	return edits, s.callWithOptions(ctx, "formattingEdits", name, 0, options.settings(), &edits)
+}
+
+// call runs a language service request for a file that is in the language service and decodes the result
+// into out. The request is interrupted if the context is done, in which case the context's error is returned.
+func (s *LanguageService) call(ctx context.Context, method, name string, offset int, out interface{}) error {
+	return s.callWithOptions(ctx, method, name, offset, nil, out)
+}
+
+// callWithOptions is like call, but also passes the JSON encoded options to the request.
+func (s *LanguageService) callWithOptions(ctx context.Context, method, name string, offset int, options interface{}, out interface{}) error {
+	optionBytes, err := json.Marshal(options)
+	if err != nil {
+		return fmt.Errorf("marshalling language service %s options: %w", method, err)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	name = compilerPath(name)
+	if _, ok := s.files[name]; !ok {
+		return fmt.Errorf("file '%s' is not in the language service", name)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	runtime := s.cfg.Runtime
+	stop := startResettableInterruptable(ctx, runtime)
+	value, err := s.request(goja.Undefined(), runtime.ToValue(method), runtime.ToValue(name), runtime.ToValue(offset), runtime.ToValue(string(optionBytes)))
+	stop()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("running language service %s request: %w", method, err)
+	}
+	err = json.Unmarshal([]byte(value.String()), out)
+	if err != nil {
+		return fmt.Errorf("decoding language service %s response: %w", method, err)
+	}
+	return nil
+}
+
+// hostObject returns a javascript object with the functions needed by the languageService javascript
+// function. The functions are called while a request holds the lock.
+func (s *LanguageService) hostObject() *goja.Object {
+	runtime := s.cfg.Runtime
+	o := runtime.NewObject()
+	_ = o.Set("fileNames", func() goja.Value {
+		names := s.fileNames()
+		values := make([]interface{}, len(names))
+		for i, name := range names {
+			values[i] = name
+		}
+		return runtime.NewArray(values...)
+	})
+	_ = o.Set("version", func(name string) string {
+		if f, ok := s.files[compilerPath(name)]; ok {
+			return fmt.Sprint(f.version)
+		}
+		return "0"
+	})
+	_ = o.Set("readFile", func(name string) goja.Value {
+		if text, ok := s.readFile(name); ok {
+			return runtime.ToValue(text)
+		}
+		return goja.Undefined()
+	})
+	_ = o.Set("fileExists", func(name string) bool {
+		_, ok := s.readFile(name)
+		return ok
+	})
+	_ = o.Set("directoryExists", func(name string) bool {
+		name = compilerPath(name)
+		if name == "/" || s.libs != nil && name == DefaultLibLocation {
+			return true
+		}
+		for f := range s.files {
+			if strings.HasPrefix(f, name+"/") {
+				return true
+			}
+		}
+		return false
+	})
+	_ = o.Set("getDirectories", func(name string) []string {
+		prefix := strings.TrimSuffix(compilerPath(name), "/") + "/"
+		seen := make(map[string]bool)
+		dirs := []string{}
+		for f := range s.files {
+			if !strings.HasPrefix(f, prefix) {
+				continue
+			}
+			rest := strings.TrimPrefix(f, prefix)
+			if i := strings.Index(rest, "/"); i > 0 && !seen[rest[:i]] {
+				seen[rest[:i]] = true
+				dirs = append(dirs, rest[:i])
+			}
+		}
+		sort.Strings(dirs)
+		return dirs
+	})
+	return o
+}
+
+func (s *LanguageService) fileNames() []string {
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readFile returns the text of a file in the language service, falling back to the default lib files.
+func (s *LanguageService) readFile(name string) (string, bool) {
+	name = compilerPath(name)
+	if f, ok := s.files[name]; ok {
+		return f.text, true
+	}
+	if s.libs == nil || path.Dir(name) != DefaultLibLocation {
+		return "", false
+	}
+	b, err := fs.ReadFile(s.libs, path.Base(name))
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// languageService is a javascript function expression that creates a ts.LanguageService from the object
+// returned by LanguageService.hostObject and the JSON encoded compiler options. It returns a function that runs a request
+// against the language service and returns the JSON encoded result.
+const languageService = `function (host, options) {
+	// The options may be either tsconfig.json style options or the parsed options from a TSConfig
+	var compilerOptions = ts.fixupCompilerOptions(JSON.parse(options), []);
+	if (compilerOptions.lib) {
+		compilerOptions.lib = compilerOptions.lib.map(function (lib) { return ts.libMap.get(lib.toLowerCase()) || lib; });
+	}
+	var service = ts.createLanguageService({
+		getScriptFileNames: function () { return host.fileNames(); },
+		getScriptVersion: function (fileName) { return host.version(fileName); },
+		getScriptSnapshot: function (fileName) {
+			var text = host.readFile(fileName);
+			return text === undefined ? undefined : ts.ScriptSnapshot.fromString(text);
+		},
+		getCurrentDirectory: function () { return "/"; },
+		getCompilationSettings: function () { return compilerOptions; },
+		getDefaultLibFileName: function (options) { return "` + DefaultLibLocation + `/" + ts.getDefaultLibFileName(options); },
+		fileExists: function (fileName) { return host.fileExists(fileName); },
+		readFile: function (fileName) { return host.readFile(fileName); },
+		directoryExists: function (directoryName) { return host.directoryExists(directoryName); },
+		getDirectories: function (path) { return host.getDirectories(path); },
+		useCaseSensitiveFileNames: function () { return true; },
+		getNewLine: function () { return "\n"; }
+	}, ts.createDocumentRegistry());
+
+	function text(parts) {
+		return typeof parts === "string" ? parts : ts.displayPartsToString(parts || []);
+	}
+	function span(s) {
+		return { start: s.start, length: s.length };
+	}
+	function location(fileName, textSpan) {
+		var out = { fileName: fileName, textSpan: span(textSpan) };
+		var file = service.getProgram().getSourceFile(fileName);
+		if (file) {
+			var start = ts.getLineAndCharacterOfPosition(file, textSpan.start);
+			var end = ts.getLineAndCharacterOfPosition(file, textSpan.start + textSpan.length);
+			out.line = start.line + 1;
+			out.column = start.character + 1;
+			out.endLine = end.line + 1;
+			out.endColumn = end.character + 1;
+		}
+		return out;
+	}
+
+	var requests = {
+		completions: function (fileName, position) {
+			var info = service.getCompletionsAtPosition(fileName, position, {});
+			if (!info) {
+				return null;
+			}
+			return {
+				isMemberCompletion: info.isMemberCompletion,
+				isNewIdentifierLocation: info.isNewIdentifierLocation,
+				entries: info.entries.map(function (e) {
+					return {
+						name: e.name,
+						kind: e.kind,
+						kindModifiers: e.kindModifiers,
+						sortText: e.sortText,
+						insertText: e.insertText,
+						replacementSpan: e.replacementSpan && span(e.replacementSpan)
+					};
+				})
+			};
+		},
+		quickInfo: function (fileName, position) {
+			var info = service.getQuickInfoAtPosition(fileName, position);
+			if (!info) {
+				return null;
+			}
+			return {
+				kind: info.kind,
+				kindModifiers: info.kindModifiers,
+				textSpan: span(info.textSpan),
+				display: text(info.displayParts),
+				documentation: text(info.documentation),
+				tags: (info.tags || []).map(function (t) { return { name: t.name, text: text(t.text) }; })
+			};
+		},
+		definition: function (fileName, position) {
+			return (service.getDefinitionAtPosition(fileName, position) || []).map(function (d) {
+				var out = location(d.fileName, d.textSpan);
+				out.kind = d.kind;
+				out.name = d.name;
+				out.containerKind = d.containerKind;
+				out.containerName = d.containerName;
+				return out;
+			});
+		},
+		signatureHelp: function (fileName, position) {
+			var help = service.getSignatureHelpItems(fileName, position, {});
+			if (!help) {
+				return null;
+			}
+			return {
+				items: help.items.map(function (item) {
+					var parameters = item.parameters.map(function (p) {
+						return { name: p.name, label: text(p.displayParts), documentation: text(p.documentation), isOptional: p.isOptional };
+					});
+					return {
+						label: text(item.prefixDisplayParts) +
+							parameters.map(function (p) { return p.label; }).join(text(item.separatorDisplayParts)) +
+							text(item.suffixDisplayParts),
+						documentation: text(item.documentation),
+						parameters: parameters,
+						isVariadic: item.isVariadic
+					};
+				}),
+				applicableSpan: span(help.applicableSpan),
+				selectedItemIndex: help.selectedItemIndex,
+				argumentIndex: help.argumentIndex,
+				argumentCount: help.argumentCount
+			};
+		},
+		references: function (fileName, position) {
+			var out = [];
+			(service.findReferences(fileName, position) || []).forEach(function (symbol) {
+				symbol.references.forEach(function (r) {
+					var entry = location(r.fileName, r.textSpan);
+					entry.isWriteAccess = !!r.isWriteAccess;
+					entry.isDefinition = !!r.isDefinition;
+					out.push(entry);
+				});
+			});
+			return out;
+		},
+		diagnostics: function (fileName) {
+			return (` + diagnosticsConverter + `)(service.getSyntacticDiagnostics(fileName).concat(service.getSemanticDiagnostics(fileName)));
+		},
+		formattingEdits: function (fileName, position, options) {
+			var settings = ts.getDefaultFormatCodeSettings(options.newLineCharacter || "\n");
+			for (var name in options) {
+				settings[name] = options[name];
+			}
+			return service.getFormattingEditsForDocument(fileName, settings).map(function (e) {
+				return { textSpan: span(e.span), newText: e.newText };
+			});
+		}
+	};
+	return function (method, fileName, position, options) {
+		return JSON.stringify(requests[method](fileName, position, JSON.parse(options)));
+	};
+}`
@@ -0,0 +1,138 @@
+package typescript
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageService(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	registry.RegisterLibs("v4.9.3", fstest.MapFS{
+		"lib.d.ts": {Data: []byte(minimalLib)},
+	})
+	service, err := NewLanguageService(WithRegistry(registry), WithVersion("v4.9.3"))
+	require.NoError(t, err)
+
+	math := strings.Join([]string{
+		"/** Adds two numbers. */",
+		"export function add(a: number, b: number): number { return a + b; }",
+		"export const pi = 3.14;",
+	}, "\n")
+	index := strings.Join([]string{
+		"import { add, pi } from './math';",
+		"let total = add(1, pi);",
+		"total = add(total, 2);",
+		"const s: string = total;",
+	}, "\n")
+	service.SetFile("math.ts", math)
+	service.SetFile("/index.ts", index)
+	require.Equal(t, []string{"/index.ts", "/math.ts"}, service.Files())
+
+	t.Run("quick info", func(t *testing.T) {
+		info, err := service.QuickInfo("index.ts", strings.Index(index, "add(1"))
+		require.NoError(t, err)
+		require.Equal(t, "alias", info.Kind)
+		require.Contains(t, info.Display, "add(a: number, b: number): number")
+		require.Equal(t, "Adds two numbers.", info.Documentation)
+
+		info, err = service.QuickInfo("index.ts", strings.Index(index, ", ")+1)
+		require.NoError(t, err)
+		require.Nil(t, info)
+	})
+
+	t.Run("completions", func(t *testing.T) {
+		service.SetFile("completions.ts", "import * as math from './math';\nmath.")
+		info, err := service.Completions("completions.ts", len("import * as math from './math';\nmath."))
+		require.NoError(t, err)
+		require.True(t, info.IsMemberCompletion)
+		var names []string
+		for _, e := range info.Entries {
+			names = append(names, e.Name)
+		}
+		require.ElementsMatch(t, []string{"add", "pi"}, names)
+		service.RemoveFile("completions.ts")
+		require.Equal(t, []string{"/index.ts", "/math.ts"}, service.Files())
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		definitions, err := service.Definition("index.ts", strings.Index(index, "add(1"))
+		require.NoError(t, err)
+		require.Len(t, definitions, 1)
+		require.Equal(t, "/math.ts", definitions[0].FileName)
+		require.Equal(t, "add", definitions[0].Name)
+		require.Equal(t, 2, definitions[0].Line)
+		require.Equal(t, strings.Index(math, "add"), definitions[0].TextSpan.Start)
+	})
+
+	t.Run("signature help", func(t *testing.T) {
+		help, err := service.SignatureHelp("index.ts", strings.Index(index, "pi)"))
+		require.NoError(t, err)
+		require.Len(t, help.Items, 1)
+		require.Equal(t, "add(a: number, b: number): number", help.Items[0].Label)
+		require.Equal(t, 1, help.ArgumentIndex)
+		require.Equal(t, "b: number", help.Items[0].Parameters[1].Label)
+
+		help, err = service.SignatureHelp("index.ts", 0)
+		require.NoError(t, err)
+		require.Nil(t, help)
+	})
+
+	t.Run("references", func(t *testing.T) {
+		references, err := service.References("index.ts", strings.Index(index, "total"))
+		require.NoError(t, err)
+		require.Len(t, references, 4)
+		var writes, definitions int
+		for _, r := range references {
+			require.Equal(t, "/index.ts", r.FileName)
+			if r.IsWriteAccess {
+				writes++
+			}
+			if r.IsDefinition {
+				definitions++
+			}
+		}
+		require.Equal(t, 2, writes)
+		require.Equal(t, 1, definitions)
+		require.Equal(t, 2, references[0].Line)
+		require.Equal(t, 5, references[0].Column)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		diagnostics, err := service.Diagnostics("index.ts")
+		require.NoError(t, err)
+		require.Len(t, diagnostics, 1)
+		require.Equal(t, 2322, diagnostics[0].Code)
+		require.Equal(t, 4, diagnostics[0].Line)
+
+		service.SetFile("index.ts", strings.Replace(index, "const s: string", "const s: number", 1))
+		diagnostics, err = service.Diagnostics("index.ts")
+		require.NoError(t, err)
+		require.Empty(t, diagnostics)
+	})
+
+	t.Run("unknown file", func(t *testing.T) {
+		_, err := service.QuickInfo("missing.ts", 0)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not in the language service")
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := service.QuickInfoCtx(ctx, "index.ts", strings.Index(index, "add(1"))
+		require.ErrorIs(t, err, context.Canceled)
+		_, err = service.DiagnosticsCtx(ctx, "index.ts")
+		require.ErrorIs(t, err, context.Canceled)
+
+		info, err := service.QuickInfoCtx(context.Background(), "index.ts", strings.Index(index, "add(1"))
+		require.NoError(t, err)
+		require.Equal(t, "alias", info.Kind)
+	})
+}
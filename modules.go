@@ -0,0 +1,79 @@
+package typescript
+
+import (
+	"fmt"
+
+	"github.com/dop251/goja"
+)
+
+// ModuleLoader returns the value of a module provided by the host, such as the exports object of the module.
+// It is called at most once per evaluation, the first time a script requires the module.
+type ModuleLoader func(runtime *goja.Runtime) (goja.Value, error)
+
+// WithModule makes a module available to the evaluated script under the provided module specifier. Scripts
+// import the module as usual, and the transpiled require call (or AMD dependency when using an AMD loader such
+// as almond) resolves to the value returned by the loader.
+func WithModule(name string, loader ModuleLoader) EvaluateOptionFunc {
+	return func(cfg *EvaluateConfig) {
+		if cfg.Modules == nil {
+			cfg.Modules = make(map[string]ModuleLoader)
+		}
+		cfg.Modules[name] = loader
+	}
+}
+
+// installModules declares the configured modules in the runtime. A global require function is installed that
+// resolves the modules, and delegates any other module to the require function that was previously declared, if
+// any. If an AMD loader is present, the modules are also defined with the loader.
+func installModules(runtime *goja.Runtime, modules map[string]ModuleLoader) error {
+	loaded := make(map[string]goja.Value)
+	load := func(name string) (goja.Value, bool) {
+		if v, ok := loaded[name]; ok {
+			return v, true
+		}
+		loader, ok := modules[name]
+		if !ok {
+			return nil, false
+		}
+		v, err := loader(runtime)
+		if err != nil {
+			panic(runtime.NewGoError(fmt.Errorf("loading module '%s': %w", name, err)))
+		}
+		loaded[name] = v
+		return v, true
+	}
+
+	previous, hasPrevious := goja.AssertFunction(runtime.Get("require"))
+	err := runtime.Set("require", func(call goja.FunctionCall) goja.Value {
+		if v, ok := load(call.Argument(0).String()); ok {
+			return v
+		}
+		if hasPrevious {
+			v, err := previous(call.This, call.Arguments...)
+			if err != nil {
+				panic(err)
+			}
+			return v
+		}
+		panic(runtime.NewTypeError("cannot find module '%s'", call.Argument(0).String()))
+	})
+	if err != nil {
+		return fmt.Errorf("setting require function: %w", err)
+	}
+
+	define, ok := goja.AssertFunction(runtime.Get("define"))
+	if !ok {
+		return nil
+	}
+	for name := range modules {
+		name := name
+		_, err = define(goja.Undefined(), runtime.ToValue(name), runtime.NewArray(), runtime.ToValue(func() goja.Value {
+			v, _ := load(name)
+			return v
+		}))
+		if err != nil {
+			return fmt.Errorf("defining module '%s': %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// A minimal JSX runtime for rendering components to HTML strings. Components are plain functions that
+// receive their props (including children) and return elements, strings, numbers, arrays or null.
+var h, Fragment, renderToString, __jsxRuntime;
+(function () {
+    var voidElements = {
+        area: true, base: true, br: true, col: true, embed: true, hr: true, img: true, input: true,
+        link: true, meta: true, param: true, source: true, track: true, wbr: true
+    };
+    var attributeNames = { className: "class", htmlFor: "for" };
+    // Props that aren't valid attribute names, such as names with spaces, quotes, = or >, are skipped since
+    // they can't be written into the markup safely
+    var validAttributeName = /^[A-Za-z_:][A-Za-z0-9_:.\-]*$/;
+
+    function escape(s) {
+        return String(s).replace(/[&<>"']/g, function (c) {
+            return { "&": "&amp;", "<": "&lt;", ">": "&gt;", "\"": "&quot;", "'": "&#39;" }[c];
+        });
+    }
+
+    function hyphenate(s) {
+        return s.replace(/[A-Z]/g, function (c) { return "-" + c.toLowerCase(); });
+    }
+
+    function style(value) {
+        if (typeof value !== "object") {
+            return value;
+        }
+        return Object.keys(value).map(function (k) { return hyphenate(k) + ":" + value[k]; }).join(";");
+    }
+
+    function attributes(props) {
+        var out = "";
+        Object.keys(props).forEach(function (k) {
+            var value = props[k];
+            if (k === "children" || k === "key" || k === "ref" || k === "dangerouslySetInnerHTML" ||
+                value === null || value === undefined || value === false || typeof value === "function") {
+                return;
+            }
+            var name = attributeNames[k] || k;
+            if (!validAttributeName.test(name)) {
+                return;
+            }
+            if (value === true) {
+                out += " " + name;
+            } else {
+                out += " " + name + "=\"" + escape(k === "style" ? style(value) : value) + "\"";
+            }
+        });
+        return out;
+    }
+
+    function render(node) {
+        if (node === null || node === undefined || typeof node === "boolean") {
+            return "";
+        }
+        if (Array.isArray(node)) {
+            return node.map(render).join("");
+        }
+        if (typeof node !== "object") {
+            return escape(node);
+        }
+        if (typeof node.type === "function") {
+            return render(node.type(node.props));
+        }
+        var props = node.props;
+        var html = "<" + node.type + attributes(props) + ">";
+        if (voidElements[node.type]) {
+            return html;
+        }
+        if (props.dangerouslySetInnerHTML) {
+            html += props.dangerouslySetInnerHTML.__html;
+        } else {
+            html += render(props.children);
+        }
+        return html + "</" + node.type + ">";
+    }
+
+    function jsx(type, props, key) {
+        return { type: type, props: props || {}, key: key };
+    }
+
+    h = function (type, props) {
+        var p = {};
+        for (var k in props) {
+            if (Object.prototype.hasOwnProperty.call(props, k)) {
+                p[k] = props[k];
+            }
+        }
+        var children = Array.prototype.slice.call(arguments, 2);
+        if (children.length > 0) {
+            p.children = children.length === 1 ? children[0] : children;
+        }
+        return jsx(type, p, p.key);
+    };
+    Fragment = function (props) {
+        return props.children;
+    };
+    renderToString = render;
+    __jsxRuntime = { jsx: jsx, jsxs: jsx, jsxDEV: jsx, Fragment: Fragment };
+})();
@@ -4,3 +4,9 @@ import _ "embed"
 
 //go:embed almond.js
 var Almond string
+
+// JSX is a minimal JSX runtime that declares the global h, Fragment and renderToString functions
+// and the __jsxRuntime object used by the automatic JSX runtime.
+//
+//go:embed jsx.js
+var JSX string
@@ -0,0 +1,344 @@
+package typescript
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// SourceFile is the syntax tree of a parsed typescript file.
+type SourceFile struct {
+	FileName          string
+	Text              string
+	IsDeclarationFile bool
+	// Statements are the top-level statements of the file.
+	Statements []*Node
+	// Comments are all of the comments in the file, in the order that they appear.
+	Comments []Comment
+	// Diagnostics are the syntax errors reported by the parser.
+	Diagnostics []Diagnostic
+}
+
+// Walk calls fn for every node in the file in depth-first order, see Node.Walk.
+func (f *SourceFile) Walk(fn func(node *Node) bool) {
+	for _, node := range f.Statements {
+		node.Walk(fn)
+	}
+}
+
+// Node is a node in the syntax tree of a typescript file.
+type Node struct {
+	// Kind is the name of the node's ts.SyntaxKind, for example "CallExpression" or "Identifier".
+	Kind string
+	// Pos is the UTF-16 offset of the node in the file, including any leading comments and whitespace.
+	Pos int
+	// Start and End are the UTF-16 offsets of the node in the file, excluding any leading comments
+	// and whitespace.
+	Start int
+	End   int
+	// Text is the source text of the node.
+	Text string
+	// Flags are the names of the ts.NodeFlags set on the node, for example "Const" for a const declaration.
+	Flags []string
+	// Value is the text of identifiers and the value of literals, with any escape sequences resolved.
+	Value string
+	// JSDoc are the doc comments attached to the node.
+	JSDoc    []JSDoc
+	Parent   *Node
+	Children []*Node
+
+	index int
+}
+
+// Walk calls fn for the node and its descendants in depth-first order. The children of a node are skipped
+// if fn returns false.
+func (n *Node) Walk(fn func(node *Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
+
+// HasFlag returns true if the ts.NodeFlags flag with the provided name is set on the node.
+func (n *Node) HasFlag(flag string) bool {
+	for _, f := range n.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Comment is a comment in a typescript file.
+type Comment struct {
+	// Kind is either "SingleLineCommentTrivia" or "MultiLineCommentTrivia".
+	Kind string `json:"kind"`
+	// Pos and End are the UTF-16 offsets of the comment in the file.
+	Pos                int    `json:"pos"`
+	End                int    `json:"end"`
+	Text               string `json:"text"`
+	HasTrailingNewLine bool   `json:"hasTrailingNewLine"`
+}
+
+// JSDoc is a doc comment.
+type JSDoc struct {
+	// Comment is the text of the comment before any tags.
+	Comment string           `json:"comment"`
+	Tags    []ParsedJSDocTag `json:"tags"`
+}
+
+// ParsedJSDocTag is a tag from a parsed doc comment. Unlike the tags reported by the language service, the
+// parameter name and type of the tag are available separately from its text.
+type ParsedJSDocTag struct {
+	JSDocTag
+	// ParamName is the name of the parameter or property documented by a @param or @property tag.
+	ParamName string `json:"paramName,omitempty"`
+	// TypeExpression is the type of a tag such as @param {string} or @returns {number}.
+	TypeExpression string `json:"typeExpression,omitempty"`
+}
+
+// Parse calls ParseCtx using the default background context.
+func Parse(src io.Reader, opts ...TranspileOptionFunc) (*SourceFile, error) {
+	return ParseCtx(context.Background(), src, opts...)
+}
+
+// ParseString parses the provided typescript string.
+func ParseString(src string, opts ...TranspileOptionFunc) (*SourceFile, error) {
+	return ParseCtx(context.Background(), strings.NewReader(src), opts...)
+}
+
+// ParseCtx parses the bytes read from src with ts.createSourceFile and returns its syntax tree. The file name
+// decides how the file is parsed, for example .tsx enables JSX, and defaults to module.ts. If src has a Name
+// method, such as an *os.File, the name is used as the default file name. Syntax errors are returned as
+// diagnostics, unless FailOnDiagnosticErrors is set in which case a *DiagnosticsError is returned.
+func ParseCtx(ctx context.Context, src io.Reader, opts ...TranspileOptionFunc) (*SourceFile, error) {
+	b, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("reading src: %w", err)
+	}
+	cfg, done, err := newCompilerConfig(ctx, append([]TranspileOptionFunc{WithFileName(readerName(src))}, opts...))
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	fileName := cfg.FileName
+	if fileName == "" {
+		fileName = "module.ts"
+	}
+	fileNameBytes, err := json.Marshal(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling file name: %w", err)
+	}
+	file, err := parseSourceFile(cfg, b, string(fileNameBytes))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.FailOnDiagnosticErrors && hasErrors(file.Diagnostics) {
+		return nil, &DiagnosticsError{Diagnostics: file.Diagnostics}
+	}
+	return file, nil
+}
+
+// parseSourceFile parses the script in the config's runtime, the file name is a javascript expression. The
+// nodes are indexed in the order of a depth-first walk of the source file with ts.forEachChild.
+func parseSourceFile(cfg *Config, script []byte, fileName string) (*SourceFile, error) {
+	s := fmt.Sprintf(`(%s)(%s, %s('%s'))`, sourceFileConverter, fileName, cfg.decoderName, base64.StdEncoding.EncodeToString(script))
+	if cfg.Verbose {
+		log.Println(s)
+	}
+	value, err := cfg.Runtime.RunString(s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing script: %w", err)
+	}
+	var parsed struct {
+		FileName          string       `json:"fileName"`
+		Text              string       `json:"text"`
+		IsDeclarationFile bool         `json:"isDeclarationFile"`
+		Comments          []Comment    `json:"comments"`
+		Diagnostics       []Diagnostic `json:"diagnostics"`
+		Nodes             []struct {
+			Kind   string   `json:"kind"`
+			Pos    int      `json:"pos"`
+			Start  int      `json:"start"`
+			End    int      `json:"end"`
+			Flags  []string `json:"flags"`
+			Value  string   `json:"value"`
+			JSDoc  []JSDoc  `json:"jsDoc"`
+			Parent int      `json:"parent"`
+		} `json:"nodes"`
+	}
+	err = json.Unmarshal([]byte(value.String()), &parsed)
+	if err != nil {
+		return nil, fmt.Errorf("decoding syntax tree: %w", err)
+	}
+	file := &SourceFile{
+		FileName:          parsed.FileName,
+		Text:              parsed.Text,
+		IsDeclarationFile: parsed.IsDeclarationFile,
+		Comments:          parsed.Comments,
+		Diagnostics:       parsed.Diagnostics,
+	}
+	// The text of the nodes is sliced from the file's text rather than serialized for every node
+	offsets := utf16Offsets(file.Text)
+	nodes := make([]*Node, len(parsed.Nodes))
+	for i, p := range parsed.Nodes {
+		node := &Node{
+			Kind:  p.Kind,
+			Pos:   p.Pos,
+			Start: p.Start,
+			End:   p.End,
+			Text:  sliceUTF16(file.Text, offsets, p.Start, p.End),
+			Flags: p.Flags,
+			Value: p.Value,
+			JSDoc: p.JSDoc,
+			index: i,
+		}
+		nodes[i] = node
+		if p.Parent >= 0 {
+			node.Parent = nodes[p.Parent]
+			node.Parent.Children = append(node.Parent.Children, node)
+		} else if node.Kind != "EndOfFileToken" {
+			file.Statements = append(file.Statements, node)
+		}
+	}
+	return file, nil
+}
+
+// utf16Offsets returns the byte offset in text of every UTF-16 offset, including the offset of the end of the
+// text. The second unit of a surrogate pair has the offset of the character it belongs to.
+func utf16Offsets(text string) []int {
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		offsets = append(offsets, i)
+		if r >= 0x10000 {
+			offsets = append(offsets, i)
+		}
+	}
+	return append(offsets, len(text))
+}
+
+// sliceUTF16 returns the text between the UTF-16 offsets, using the byte offsets returned by utf16Offsets.
+func sliceUTF16(text string, offsets []int, start, end int) string {
+	if start < 0 || end < start || end >= len(offsets) {
+		return ""
+	}
+	return text[offsets[start]:offsets[end]]
+}
+
+// syntaxKindNames is a javascript function expression that returns the names of the ts.SyntaxKind values.
+// Several kinds have aliases that mark the boundaries of ranges of kinds, such as FirstStatement, which are
+// declared after the kind's own name and so are skipped.
+const syntaxKindNames = `function () {
+	var names = {};
+	for (var name in ts.SyntaxKind) {
+		var kind = ts.SyntaxKind[name];
+		if (typeof kind === "number" && !(kind in names)) {
+			names[kind] = name;
+		}
+	}
+	return names;
+}`
+
+// sourceFileConverter is a javascript function expression that parses a file and converts its syntax tree
+// into plain objects that can be JSON-serialized. The nodes are flattened into a list in depth-first order,
+// with each node referring to the index of its parent.
+const sourceFileConverter = `function (fileName, text) {
+	var kinds = (` + syntaxKindNames + `)();
+	var flags = [];
+	var seenFlags = {};
+	for (var name in ts.NodeFlags) {
+		var flag = ts.NodeFlags[name];
+		// Only single flags are included, not the combinations of flags
+		if (typeof flag === "number" && flag > 0 && (flag & (flag - 1)) === 0 && !seenFlags[flag]) {
+			seenFlags[flag] = true;
+			flags.push([flag, name]);
+		}
+	}
+	var sourceFile = ts.createSourceFile(fileName, text, ts.ScriptTarget.Latest, true);
+
+	function commentText(comment) {
+		// Comments are a list of nodes since typescript 4.3, to support links
+		return typeof comment === "string" || comment === undefined ? comment || "" : ts.getTextOfJSDocComment(comment) || "";
+	}
+	function jsDoc(node) {
+		return (node.jsDoc || []).map(function (doc) {
+			return {
+				comment: commentText(doc.comment),
+				tags: (doc.tags || []).map(function (tag) {
+					return {
+						name: tag.tagName.text,
+						text: commentText(tag.comment),
+						paramName: tag.name && tag.name.getText(sourceFile),
+						typeExpression: tag.typeExpression && tag.typeExpression.type && tag.typeExpression.type.getText(sourceFile)
+					};
+				})
+			};
+		});
+	}
+	function value(node) {
+		if (ts.isIdentifier(node) || ts.isPrivateIdentifier(node)) {
+			return ts.idText(node);
+		}
+		if (ts.isLiteralKind(node.kind) || ts.isTemplateLiteralKind(node.kind)) {
+			return node.text;
+		}
+	}
+
+	var nodes = [];
+	function walk(parent) {
+		return function (node) {
+			var start = node.getStart(sourceFile);
+			nodes.push({
+				kind: kinds[node.kind],
+				pos: node.pos,
+				start: start,
+				end: node.end,
+				flags: flags.filter(function (f) { return node.flags & f[0]; }).map(function (f) { return f[1]; }),
+				value: value(node),
+				jsDoc: jsDoc(node),
+				parent: parent
+			});
+			ts.forEachChild(node, walk(nodes.length - 1));
+		};
+	}
+	ts.forEachChild(sourceFile, walk(-1));
+
+	// Every comment is in the leading or trailing trivia of a token, so the comments are found by walking
+	// every token
+	var comments = [];
+	var seen = {};
+	function add(ranges) {
+		(ranges || []).forEach(function (c) {
+			if (!seen[c.pos]) {
+				seen[c.pos] = true;
+				comments.push({ kind: kinds[c.kind], pos: c.pos, end: c.end, text: sourceFile.text.slice(c.pos, c.end), hasTrailingNewLine: !!c.hasTrailingNewLine });
+			}
+		});
+	}
+	function collect(node) {
+		if (node.kind >= ts.SyntaxKind.FirstJSDocNode && node.kind <= ts.SyntaxKind.LastJSDocNode) {
+			return;
+		}
+		add(ts.getLeadingCommentRanges(sourceFile.text, node.pos));
+		add(ts.getTrailingCommentRanges(sourceFile.text, node.end));
+		node.getChildren(sourceFile).forEach(collect);
+	}
+	collect(sourceFile);
+	comments.sort(function (a, b) { return a.pos - b.pos; });
+
+	return JSON.stringify({
+		fileName: sourceFile.fileName,
+		text: sourceFile.text,
+		isDeclarationFile: sourceFile.isDeclarationFile,
+		nodes: nodes,
+		comments: comments,
+		diagnostics: (` + diagnosticsConverter + `)(sourceFile.parseDiagnostics)
+	});
+}`
@@ -0,0 +1,108 @@
+package typescript
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	opts := []TranspileOptionFunc{WithRegistry(registry), WithVersion("v4.9.3")}
+
+	script := strings.Join([]string{
+		"// policy: allow",
+		"/**",
+		" * Greets someone.",
+		" * @param {string} name - who to greet",
+		" * @deprecated",
+		" */",
+		"export function greet(name: string) {",
+		"  const greeting = 'hello \\u0041'; /* trailing */",
+		"  return greeting + name + 0x10;",
+		"}",
+	}, "\n")
+	file, err := ParseString(script, opts...)
+	require.NoError(t, err)
+	require.Equal(t, "module.ts", file.FileName)
+	require.Empty(t, file.Diagnostics)
+	require.Len(t, file.Statements, 1)
+
+	fn := file.Statements[0]
+	require.Equal(t, "FunctionDeclaration", fn.Kind)
+	require.Equal(t, 0, fn.Pos)
+	require.Equal(t, strings.Index(script, "export"), fn.Start)
+	require.Equal(t, len(script), fn.End)
+	require.Len(t, fn.JSDoc, 1)
+	require.Equal(t, "Greets someone.", fn.JSDoc[0].Comment)
+	require.Equal(t, []ParsedJSDocTag{
+		{JSDocTag: JSDocTag{Name: "param", Text: "- who to greet"}, ParamName: "name", TypeExpression: "string"},
+		{JSDocTag: JSDocTag{Name: "deprecated"}},
+	}, fn.JSDoc[0].Tags)
+
+	var identifiers, literals []string
+	var constDeclaration *Node
+	file.Walk(func(node *Node) bool {
+		switch node.Kind {
+		case "Identifier":
+			identifiers = append(identifiers, node.Value)
+		case "StringLiteral", "NumericLiteral":
+			literals = append(literals, node.Value)
+		case "VariableDeclarationList":
+			constDeclaration = node
+		}
+		require.True(t, node.Parent == nil || node.Parent.Start <= node.Start)
+		return true
+	})
+	require.Equal(t, []string{"greet", "name", "greeting", "greeting", "name"}, identifiers)
+	require.Equal(t, []string{"hello A", "16"}, literals)
+	require.True(t, constDeclaration.HasFlag("Const"))
+	require.Equal(t, "const greeting = 'hello \\u0041'", constDeclaration.Text)
+
+	require.Len(t, file.Comments, 3)
+	require.Equal(t, Comment{Kind: "SingleLineCommentTrivia", Pos: 0, End: 16, Text: "// policy: allow", HasTrailingNewLine: true}, file.Comments[0])
+	require.Equal(t, "MultiLineCommentTrivia", file.Comments[1].Kind)
+	require.True(t, strings.HasPrefix(file.Comments[1].Text, "/**"))
+	require.Equal(t, "/* trailing */", file.Comments[2].Text)
+
+	t.Run("syntax errors", func(t *testing.T) {
+		file, err := ParseString("let a = ;", opts...)
+		require.NoError(t, err)
+		require.Len(t, file.Diagnostics, 1)
+		require.Equal(t, 1109, file.Diagnostics[0].Code)
+
+		_, err = ParseString("let a = ;", append(opts, WithFailOnDiagnosticErrors())...)
+		var diagnosticsErr *DiagnosticsError
+		require.True(t, errors.As(err, &diagnosticsErr))
+	})
+
+	t.Run("non-ascii text", func(t *testing.T) {
+		file, err := ParseString("const s = '😀é'; let x = s;", opts...)
+		require.NoError(t, err)
+		var texts []string
+		file.Walk(func(node *Node) bool {
+			if node.Kind == "VariableDeclaration" {
+				texts = append(texts, node.Text)
+			}
+			return true
+		})
+		require.Equal(t, []string{"s = '😀é'", "x = s"}, texts)
+	})
+
+	t.Run("tsx", func(t *testing.T) {
+		file, err := ParseString("const a = <div>{b}</div>;", append(opts, WithFileName("view.tsx"))...)
+		require.NoError(t, err)
+		require.Empty(t, file.Diagnostics)
+		var kinds []string
+		file.Walk(func(node *Node) bool {
+			kinds = append(kinds, node.Kind)
+			return node.Kind != "JsxElement"
+		})
+		require.Equal(t, "JsxElement", kinds[len(kinds)-1])
+	})
+}
@@ -0,0 +1,397 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clarkmcc/go-typescript/versions"
+)
+
+// ErrPoolClosed is returned when acquiring a transpiler from a pool that has been closed.
+var ErrPoolClosed = errors.New("transpiler pool is closed")
+
+// ErrNotAcquired is returned when releasing a transpiler that wasn't acquired from the pool, or that has
+// already been released.
+var ErrNotAcquired = errors.New("transpiler wasn't acquired from the pool")
+
+// PoolConfig defines the size of each per-version pool of transpilers in a TranspilerPool.
+type PoolConfig struct {
+	// Min is the number of warm transpilers that are kept per typescript version, even when idle. They are
+	// created in the background for the pool's default version when the pool is created, and for other
+	// versions when they are first acquired. Use Warm to wait for them to be created.
+	Min int
+	// Max is the maximum number of transpilers per typescript version. Callers block until a transpiler
+	// is released once this many are in use. Defaults to the number of CPUs.
+	Max int
+	// IdleTimeout is how long a transpiler can be idle before it is discarded, as long as there are more
+	// than Min transpilers for its version. Idle transpilers are never discarded if this is zero.
+	IdleTimeout time.Duration
+}
+
+// PoolStats describes the utilization of the transpilers for a single typescript version.
+type PoolStats struct {
+	Version string
+	// Idle is the number of warm transpilers waiting to be used.
+	Idle int
+	// InUse is the number of transpilers that are acquired, or are being created.
+	InUse int
+	// Waiting is the number of callers blocked waiting for a transpiler.
+	Waiting int
+	Max     int
+}
+
+// Utilization returns the fraction of the maximum number of transpilers that are in use.
+func (s PoolStats) Utilization() float64 {
+	return float64(s.InUse) / float64(s.Max)
+}
+
+// TranspilerPool hands out warm Transpilers to concurrent callers. Transpilers are created on demand for
+// each typescript version, up to PoolConfig.Max, and all share the same registry so that each version of
+// the compiler is only compiled once.
+type TranspilerPool struct {
+	config   PoolConfig
+	opts     []TranspileOptionFunc
+	registry versions.Registry
+	// version is the default typescript version of the pool
+	version string
+
+	lock     sync.Mutex
+	pools    map[string]*versionPool
+	acquired map[*Transpiler]*versionPool
+	active   sync.WaitGroup
+	closed   chan struct{}
+	once     sync.Once
+}
+
+type versionPool struct {
+	version string
+	// tokens holds one token for every transpiler that is in use or being created
+	tokens  chan struct{}
+	idle    []idleTranspiler
+	waiting int
+}
+
+type idleTranspiler struct {
+	transpiler *Transpiler
+	since      time.Time
+}
+
+// NewTranspilerPool creates a pool of transpilers that are created with the provided options. Every transpiler
+// must have its own runtime, so the options must not include WithRuntime.
+func NewTranspilerPool(config PoolConfig, opts ...TranspileOptionFunc) *TranspilerPool {
+	if config.Max <= 0 {
+		config.Max = runtime.NumCPU()
+	}
+	if config.Min > config.Max {
+		config.Min = config.Max
+	}
+	// Resolve the registry once so that every transpiler shares it, regardless of whether the caller
+	// provided one.
+	cfg := NewDefaultConfig()
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	p := &TranspilerPool{
+		config:   config,
+		opts:     append([]TranspileOptionFunc{WithRegistry(cfg.Registry)}, opts...),
+		registry: cfg.Registry,
+		version:  cfg.TypescriptVersion,
+		pools:    make(map[string]*versionPool),
+		acquired: make(map[*Transpiler]*versionPool),
+		closed:   make(chan struct{}),
+	}
+	if config.Min > 0 {
+		go p.fill(p.version)
+	}
+	if config.IdleTimeout > 0 {
+		go p.evictIdle()
+	}
+	return p
+}
+
+// Warm creates transpilers for each of the provided typescript versions until there are at least Min
+// transpilers for each version, and returns once they have been created.
+func (p *TranspilerPool) Warm(ctx context.Context, versions ...string) error {
+	for _, version := range versions {
+		var acquired []*Transpiler
+		for i := 0; i < p.config.Min; i++ {
+			t, err := p.Acquire(ctx, version)
+			if err != nil {
+				for _, t := range acquired {
+					p.Release(t)
+				}
+				return err
+			}
+			acquired = append(acquired, t)
+		}
+		for _, t := range acquired {
+			p.Release(t)
+		}
+	}
+	return nil
+}
+
+// Acquire returns a transpiler for the specified typescript version, or the pool's default version if the
+// version is empty. The version may be a constraint if the registry is a versions.Resolver, see WithVersion.
+// If the maximum number of transpilers for the version are in use, Acquire blocks until one is released or
+// the context is done. The transpiler must be returned to the pool with Release.
+func (p *TranspilerPool) Acquire(ctx context.Context, version string) (*Transpiler, error) {
+	version, err := p.resolve(version)
+	if err != nil {
+		return nil, err
+	}
+	p.lock.Lock()
+	select {
+	case <-p.closed:
+		p.lock.Unlock()
+		return nil, ErrPoolClosed
+	default:
+	}
+	vp, created := p.versionPoolLocked(version)
+	if created && p.config.Min > 0 {
+		go p.fill(version)
+	}
+	vp.waiting++
+	p.active.Add(1)
+	p.lock.Unlock()
+
+	select {
+	case vp.tokens <- struct{}{}:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-p.closed:
+		err = ErrPoolClosed
+	}
+
+	p.lock.Lock()
+	vp.waiting--
+	if err != nil {
+		p.lock.Unlock()
+		p.active.Done()
+		return nil, err
+	}
+	if n := len(vp.idle); n > 0 {
+		t := vp.idle[n-1].transpiler
+		vp.idle = vp.idle[:n-1]
+		p.acquired[t] = vp
+		p.lock.Unlock()
+		return t, nil
+	}
+	p.lock.Unlock()
+
+	t, err := NewTranspilerCtx(ctx, append(p.opts, WithVersion(version))...)
+	if err != nil {
+		<-vp.tokens
+		p.active.Done()
+		return nil, err
+	}
+	p.lock.Lock()
+	p.acquired[t] = vp
+	p.lock.Unlock()
+	return t, nil
+}
+
+// Release returns a transpiler acquired with Acquire to the pool. It returns ErrNotAcquired, and leaves the
+// pool unchanged, if the transpiler wasn't acquired from the pool or has already been released.
+func (p *TranspilerPool) Release(t *Transpiler) error {
+	p.lock.Lock()
+	vp, ok := p.acquired[t]
+	if !ok {
+		p.lock.Unlock()
+		return ErrNotAcquired
+	}
+	delete(p.acquired, t)
+	select {
+	case <-p.closed:
+	default:
+		vp.idle = append(vp.idle, idleTranspiler{transpiler: t, since: time.Now()})
+	}
+	<-vp.tokens
+	p.lock.Unlock()
+	p.active.Done()
+	return nil
+}
+
+// resolve returns the tag of the version, or of the pool's default version if the version is empty.
+func (p *TranspilerPool) resolve(version string) (string, error) {
+	if version == "" {
+		version = p.version
+	}
+	if r, ok := p.registry.(versions.Resolver); ok {
+		var err error
+		version, err = r.Resolve(version)
+		if err != nil {
+			return "", fmt.Errorf("getting typescript source: %w", err)
+		}
+	}
+	return version, nil
+}
+
+// versionPoolLocked returns the pool for the version, creating it if it doesn't exist. This function should
+// only be called by a caller who has already acquired a lock on the pool.
+func (p *TranspilerPool) versionPoolLocked(version string) (vp *versionPool, created bool) {
+	vp, ok := p.pools[version]
+	if !ok {
+		vp = &versionPool{version: version, tokens: make(chan struct{}, p.config.Max)}
+		p.pools[version] = vp
+	}
+	return vp, !ok
+}
+
+// fill creates idle transpilers for the version until there are Min transpilers for it. It stops without
+// an error if a transpiler can't be created, in which case Acquire reports the error.
+func (p *TranspilerPool) fill(version string) {
+	version, err := p.resolve(version)
+	if err != nil {
+		return
+	}
+	for {
+		p.lock.Lock()
+		select {
+		case <-p.closed:
+			p.lock.Unlock()
+			return
+		default:
+		}
+		vp, _ := p.versionPoolLocked(version)
+		if len(vp.idle)+len(vp.tokens) >= p.config.Min {
+			p.lock.Unlock()
+			return
+		}
+		// The transpiler being created holds a token like an acquired transpiler
+		select {
+		case vp.tokens <- struct{}{}:
+		default:
+			p.lock.Unlock()
+			return
+		}
+		p.active.Add(1)
+		p.lock.Unlock()
+
+		t, err := NewTranspilerCtx(context.Background(), append(p.opts, WithVersion(version))...)
+		p.lock.Lock()
+		if err == nil {
+			select {
+			case <-p.closed:
+			default:
+				vp.idle = append(vp.idle, idleTranspiler{transpiler: t, since: time.Now()})
+			}
+		}
+		<-vp.tokens
+		p.lock.Unlock()
+		p.active.Done()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// TranspileCtx transpiles the script using a transpiler from the pool, see Transpiler.TranspileCtx.
+func (p *TranspilerPool) TranspileCtx(ctx context.Context, script io.Reader, opts ...TranspileOptionFunc) (string, error) {
+	result, err := p.TranspileModuleCtx(ctx, script, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.Code, nil
+}
+
+// TranspileString transpiles the script using a transpiler from the pool.
+func (p *TranspilerPool) TranspileString(script string, opts ...TranspileOptionFunc) (string, error) {
+	return p.TranspileCtx(context.Background(), strings.NewReader(script), opts...)
+}
+
+// TranspileModuleCtx transpiles the script using a transpiler from the pool for the typescript version
+// selected by the options, see Transpiler.TranspileModuleCtx.
+func (p *TranspilerPool) TranspileModuleCtx(ctx context.Context, script io.Reader, opts ...TranspileOptionFunc) (*TranspileResult, error) {
+	cfg := &Config{TypescriptVersion: p.version}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	t, err := p.Acquire(ctx, cfg.TypescriptVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Release(t)
+	return t.TranspileModuleCtx(ctx, script, opts...)
+}
+
+// Stats returns the utilization of the pool for each typescript version, ordered by version.
+func (p *TranspilerPool) Stats() []PoolStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	stats := make([]PoolStats, 0, len(p.pools))
+	for _, vp := range p.pools {
+		stats = append(stats, PoolStats{
+			Version: vp.version,
+			Idle:    len(vp.idle),
+			InUse:   len(vp.tokens),
+			Waiting: vp.waiting,
+			Max:     p.config.Max,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Version < stats[j].Version
+	})
+	return stats
+}
+
+// Close stops handing out transpilers and waits for every acquired transpiler to be released, or for the
+// context to be done. Callers blocked in Acquire return ErrPoolClosed.
+func (p *TranspilerPool) Close(ctx context.Context) error {
+	p.once.Do(func() {
+		p.lock.Lock()
+		close(p.closed)
+		for _, vp := range p.pools {
+			vp.idle = nil
+		}
+		p.lock.Unlock()
+	})
+	drained := make(chan struct{})
+	go func() {
+		p.active.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// evictIdle periodically discards transpilers that have been idle for longer than the idle timeout.
+func (p *TranspilerPool) evictIdle() {
+	ticker := time.NewTicker(p.config.IdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.closed:
+			return
+		case now := <-ticker.C:
+			var refill []string
+			p.lock.Lock()
+			for _, vp := range p.pools {
+				// Idle transpilers are ordered from least to most recently used
+				for len(vp.idle) > 0 && len(vp.idle)+len(vp.tokens) > p.config.Min &&
+					now.Sub(vp.idle[0].since) > p.config.IdleTimeout {
+					vp.idle = vp.idle[1:]
+				}
+				if len(vp.idle)+len(vp.tokens) < p.config.Min {
+					refill = append(refill, vp.version)
+				}
+			}
+			p.lock.Unlock()
+			// Versions can fall below Min if creating a transpiler failed
+			for _, version := range refill {
+				go p.fill(version)
+			}
+		}
+	}
+}
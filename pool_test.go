@@ -0,0 +1,126 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranspilerPool(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+
+	t.Run("concurrent transpiles", func(t *testing.T) {
+		pool := NewTranspilerPool(PoolConfig{Max: 2}, WithRegistry(registry), WithVersion("v4.2.3"))
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				output, err := pool.TranspileString("let a: number = 10;")
+				require.NoError(t, err)
+				require.Equal(t, "var a = 10;", output)
+			}()
+		}
+		wg.Wait()
+		stats := pool.Stats()
+		require.Len(t, stats, 1)
+		require.Equal(t, "v4.2.3", stats[0].Version)
+		require.Equal(t, 0, stats[0].InUse)
+		require.LessOrEqual(t, stats[0].Idle, 2)
+		require.NoError(t, pool.Close(context.Background()))
+	})
+
+	t.Run("backpressure", func(t *testing.T) {
+		pool := NewTranspilerPool(PoolConfig{Min: 1, Max: 1}, WithRegistry(registry), WithVersion("v4.2.3"))
+		require.NoError(t, pool.Warm(context.Background(), "v4.2.3"))
+		require.Equal(t, 1, pool.Stats()[0].Idle)
+
+		transpiler, err := pool.Acquire(context.Background(), "")
+		require.NoError(t, err)
+		require.Equal(t, 1.0, pool.Stats()[0].Utilization())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = pool.Acquire(ctx, "v4.2.3")
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+		pool.Release(transpiler)
+		transpiler, err = pool.Acquire(context.Background(), "v4.2.3")
+		require.NoError(t, err)
+		pool.Release(transpiler)
+		require.NoError(t, pool.Close(context.Background()))
+	})
+
+	t.Run("idle eviction", func(t *testing.T) {
+		pool := NewTranspilerPool(PoolConfig{Min: 1, Max: 2, IdleTimeout: 20 * time.Millisecond},
+			WithRegistry(registry), WithVersion("v4.2.3"))
+		a, err := pool.Acquire(context.Background(), "")
+		require.NoError(t, err)
+		b, err := pool.Acquire(context.Background(), "")
+		require.NoError(t, err)
+		pool.Release(a)
+		pool.Release(b)
+		require.Equal(t, 2, pool.Stats()[0].Idle)
+		require.Eventually(t, func() bool {
+			return pool.Stats()[0].Idle == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, pool.Close(context.Background()))
+	})
+
+	t.Run("close", func(t *testing.T) {
+		pool := NewTranspilerPool(PoolConfig{Max: 1}, WithRegistry(registry), WithVersion("v4.2.3"))
+		transpiler, err := pool.Acquire(context.Background(), "")
+		require.NoError(t, err)
+
+		// Close must wait for the acquired transpiler to be released
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.True(t, errors.Is(pool.Close(ctx), context.DeadlineExceeded))
+
+		_, err = pool.Acquire(context.Background(), "")
+		require.True(t, errors.Is(err, ErrPoolClosed))
+
+		pool.Release(transpiler)
+		require.NoError(t, pool.Close(context.Background()))
+		require.Equal(t, 0, pool.Stats()[0].Idle)
+	})
+
+	t.Run("pre-warmed", func(t *testing.T) {
+		pool := NewTranspilerPool(PoolConfig{Min: 1, Max: 2}, WithRegistry(registry), WithVersion("v4.2.3"))
+		require.Eventually(t, func() bool {
+			stats := pool.Stats()
+			return len(stats) == 1 && stats[0].Idle == 1 && stats[0].InUse == 0
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, pool.Close(context.Background()))
+	})
+
+	t.Run("release", func(t *testing.T) {
+		pool := NewTranspilerPool(PoolConfig{Max: 1}, WithRegistry(registry), WithVersion("v4.2.3"))
+		other, err := NewTranspiler(WithRegistry(registry), WithVersion("v4.2.3"))
+		require.NoError(t, err)
+		require.True(t, errors.Is(pool.Release(other), ErrNotAcquired))
+
+		transpiler, err := pool.Acquire(context.Background(), "")
+		require.NoError(t, err)
+		require.NoError(t, pool.Release(transpiler))
+		require.True(t, errors.Is(pool.Release(transpiler), ErrNotAcquired))
+		require.Equal(t, 1, pool.Stats()[0].Idle)
+		require.Equal(t, 0, pool.Stats()[0].InUse)
+		require.NoError(t, pool.Close(context.Background()))
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		pool := NewTranspilerPool(PoolConfig{Max: 1}, WithRegistry(registry))
+		_, err := pool.TranspileString("let a: number = 10;", WithVersion("v0.0.0"))
+		require.Error(t, err)
+		// Versions that aren't registered are rejected before any transpilers are created for them
+		require.Empty(t, pool.Stats())
+	})
+}
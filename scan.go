@@ -0,0 +1,423 @@
+package typescript
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// ImportKind is the syntax used to import a module.
+type ImportKind string
+
+const (
+	// ImportDeclaration is an import statement, such as import { a } from "mod".
+	ImportDeclaration ImportKind = "import"
+	// ImportEquals is an import-equals declaration, such as import a = require("mod").
+	ImportEquals ImportKind = "import-equals"
+	// ImportReExport is an export statement with a module specifier, such as export { a } from "mod".
+	ImportReExport ImportKind = "export"
+	// ImportDynamic is a dynamic import() call.
+	ImportDynamic ImportKind = "dynamic"
+	// ImportRequire is a require() call.
+	ImportRequire ImportKind = "require"
+	// ImportType is an import type, such as let a: import("mod").A.
+	ImportType ImportKind = "import-type"
+)
+
+// ExportKind is the kind of declaration that is exported.
+type ExportKind string
+
+const (
+	ExportFunction  ExportKind = "function"
+	ExportClass     ExportKind = "class"
+	ExportVariable  ExportKind = "variable"
+	ExportEnum      ExportKind = "enum"
+	ExportNamespace ExportKind = "namespace"
+	ExportInterface ExportKind = "interface"
+	ExportType      ExportKind = "type"
+	// ExportImport is an export of a binding that was imported from another module.
+	ExportImport ExportKind = "import"
+	// ExportReExport is an export from another module, such as export { a } from "mod" or export * from "mod".
+	ExportReExport ExportKind = "reexport"
+	// ExportExpression is a default export of an expression, such as export default 10.
+	ExportExpression ExportKind = "expression"
+	// ExportUnknown is an export of a name that isn't declared at the top level of the module.
+	ExportUnknown ExportKind = "unknown"
+)
+
+// ModuleInfo is the inventory of what a module imports and exports.
+type ModuleInfo struct {
+	Imports []Import `json:"imports"`
+	Exports []Export `json:"exports"`
+	// References are the files referenced with /// <reference path="..." /> directives.
+	References []string `json:"references"`
+	// TypeReferences are the packages referenced with /// <reference types="..." /> directives.
+	TypeReferences []string `json:"typeReferences"`
+	// LibReferences are the lib files referenced with /// <reference lib="..." /> directives.
+	LibReferences []string `json:"libReferences"`
+	// Diagnostics are the syntax errors reported by the parser.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// Import is a single import of a module. The location is the location of the import statement, or of the
+// call for dynamic imports and require calls.
+type Import struct {
+	Location
+	Kind ImportKind `json:"kind"`
+	// ModuleSpecifier is the imported module. It is empty for dynamic imports and require calls when the
+	// argument isn't a string literal.
+	ModuleSpecifier string `json:"moduleSpecifier"`
+	// Default is the local name of the default import, as in import a from "mod".
+	Default string `json:"default,omitempty"`
+	// Namespace is the local name of the namespace import, as in import * as a from "mod", import a =
+	// require("mod") or const a = require("mod").
+	Namespace string `json:"namespace,omitempty"`
+	// Named are the named imports, as in import { a, b as c } from "mod" or const { a } = require("mod").
+	Named []ImportBinding `json:"named,omitempty"`
+	// IsTypeOnly is true for imports that are only used for types, such as import type { A } from "mod".
+	IsTypeOnly bool `json:"isTypeOnly"`
+}
+
+// ImportBinding is a named import.
+type ImportBinding struct {
+	// Name is the name exported by the imported module.
+	Name string `json:"name"`
+	// Alias is the local name of the binding, which is the same as the name unless it is renamed.
+	Alias      string `json:"alias"`
+	IsTypeOnly bool   `json:"isTypeOnly"`
+}
+
+// Export is a single name exported by a module. The location is the location of the exporting statement.
+type Export struct {
+	Location
+	// Name is the exported name. It is "default" for the default export, "*" for export * from "mod", and
+	// "export=" for export = a.
+	Name string `json:"name"`
+	// LocalName is the name of the exported declaration within the module, if it differs from the name.
+	LocalName string     `json:"localName,omitempty"`
+	Kind      ExportKind `json:"kind"`
+	// IsTypeOnly is true if the export is a type, such as an interface, rather than a value.
+	IsTypeOnly bool `json:"isTypeOnly"`
+	// ModuleSpecifier is the module that re-exported names are exported from.
+	ModuleSpecifier string `json:"moduleSpecifier,omitempty"`
+}
+
+// ScanModule calls ScanModuleCtx using the default background context.
+func ScanModule(src io.Reader, opts ...TranspileOptionFunc) (*ModuleInfo, error) {
+	return ScanModuleCtx(context.Background(), src, opts...)
+}
+
+// ScanModuleString scans the provided typescript string.
+func ScanModuleString(src string, opts ...TranspileOptionFunc) (*ModuleInfo, error) {
+	return ScanModuleCtx(context.Background(), strings.NewReader(src), opts...)
+}
+
+// ScanModuleCtx returns what the module read from src imports and exports without executing it. Triple-slash
+// references are found with ts.preProcessFile, and the imports and exports are found by walking the module's
+// syntax tree. Syntax errors are returned as diagnostics, unless FailOnDiagnosticErrors is set in which case a
+// *DiagnosticsError is returned.
+func ScanModuleCtx(ctx context.Context, src io.Reader, opts ...TranspileOptionFunc) (*ModuleInfo, error) {
+	b, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("reading src: %w", err)
+	}
+	cfg, done, err := newCompilerConfig(ctx, append([]TranspileOptionFunc{WithFileName(readerName(src))}, opts...))
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	fileName := cfg.FileName
+	if fileName == "" {
+		fileName = "module.ts"
+	}
+	fileNameBytes, err := json.Marshal(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling file name: %w", err)
+	}
+	s := fmt.Sprintf(`(%s)(%s, %s('%s'))`, moduleScanner, fileNameBytes, cfg.decoderName, base64.StdEncoding.EncodeToString(b))
+	if cfg.Verbose {
+		log.Println(s)
+	}
+	value, err := cfg.Runtime.RunString(s)
+	if err != nil {
+		return nil, fmt.Errorf("scanning module: %w", err)
+	}
+	var info ModuleInfo
+	err = json.Unmarshal([]byte(value.String()), &info)
+	if err != nil {
+		return nil, fmt.Errorf("decoding module info: %w", err)
+	}
+	if cfg.FailOnDiagnosticErrors && hasErrors(info.Diagnostics) {
+		return nil, &DiagnosticsError{Diagnostics: info.Diagnostics}
+	}
+	return &info, nil
+}
+
+// moduleScanner is a javascript function expression that returns the JSON encoded ModuleInfo of a file.
+const moduleScanner = `function (fileName, text) {
+	var SyntaxKind = ts.SyntaxKind;
+	var sourceFile = ts.createSourceFile(fileName, text, ts.ScriptTarget.Latest, true);
+	var preProcessed = ts.preProcessFile(text, true, true);
+	var imports = [];
+	var exports = [];
+
+	function location(node) {
+		var start = node.getStart(sourceFile);
+		var startLC = ts.getLineAndCharacterOfPosition(sourceFile, start);
+		var endLC = ts.getLineAndCharacterOfPosition(sourceFile, node.end);
+		return {
+			fileName: sourceFile.fileName,
+			textSpan: { start: start, length: node.end - start },
+			line: startLC.line + 1,
+			column: startLC.character + 1,
+			endLine: endLC.line + 1,
+			endColumn: endLC.character + 1
+		};
+	}
+	function withLocation(node, o) {
+		var out = location(node);
+		for (var k in o) {
+			out[k] = o[k];
+		}
+		return out;
+	}
+	function hasModifier(node, kind) {
+		return (node.modifiers || []).some(function (m) { return m.kind === kind; });
+	}
+	function specifier(node) {
+		return node && ts.isStringLiteralLike(node) ? node.text : "";
+	}
+	function bindingNames(name, out) {
+		if (ts.isIdentifier(name)) {
+			out.push(name.text);
+		} else {
+			name.elements.forEach(function (e) {
+				if (!ts.isOmittedExpression(e)) {
+					bindingNames(e.name, out);
+				}
+			});
+		}
+		return out;
+	}
+
+	// The kinds of the top-level declarations, for resolving the kinds of exported local names
+	var declarations = {};
+	var importBindings = {};
+	function declare(name, kind, isTypeOnly) {
+		// Declarations can be merged, such as a function and namespace with the same name. Values win.
+		var existing = declarations[name];
+		if (!existing || existing.isTypeOnly) {
+			declarations[name] = { kind: kind, isTypeOnly: isTypeOnly };
+		}
+	}
+	function declarationKind(node) {
+		switch (node.kind) {
+			case SyntaxKind.FunctionDeclaration: return ["function", false];
+			case SyntaxKind.ClassDeclaration: return ["class", false];
+			case SyntaxKind.EnumDeclaration: return ["enum", false];
+			case SyntaxKind.ModuleDeclaration: return ["namespace", false];
+			case SyntaxKind.InterfaceDeclaration: return ["interface", true];
+			case SyntaxKind.TypeAliasDeclaration: return ["type", true];
+		}
+	}
+	sourceFile.statements.forEach(function (statement) {
+		var kind = declarationKind(statement);
+		if (kind && statement.name && ts.isIdentifier(statement.name)) {
+			declare(statement.name.text, kind[0], kind[1]);
+		} else if (ts.isVariableStatement(statement)) {
+			statement.declarationList.declarations.forEach(function (d) {
+				bindingNames(d.name, []).forEach(function (name) { declare(name, "variable", false); });
+			});
+		} else if (ts.isImportDeclaration(statement) && statement.importClause) {
+			var clause = statement.importClause;
+			if (clause.name) {
+				importBindings[clause.name.text] = !!clause.isTypeOnly;
+			}
+			if (clause.namedBindings && ts.isNamespaceImport(clause.namedBindings)) {
+				importBindings[clause.namedBindings.name.text] = !!clause.isTypeOnly;
+			} else if (clause.namedBindings) {
+				clause.namedBindings.elements.forEach(function (e) {
+					importBindings[e.name.text] = !!(clause.isTypeOnly || e.isTypeOnly);
+				});
+			}
+		} else if (ts.isImportEqualsDeclaration(statement)) {
+			importBindings[statement.name.text] = !!statement.isTypeOnly;
+		}
+	});
+	function resolve(name) {
+		if (declarations[name]) {
+			return declarations[name];
+		}
+		if (name in importBindings) {
+			return { kind: "import", isTypeOnly: importBindings[name] };
+		}
+		return { kind: "unknown", isTypeOnly: false };
+	}
+
+	function visitImportDeclaration(node) {
+		var out = { kind: "import", moduleSpecifier: specifier(node.moduleSpecifier), isTypeOnly: false };
+		var clause = node.importClause;
+		if (clause) {
+			out.isTypeOnly = !!clause.isTypeOnly;
+			if (clause.name) {
+				out.default = clause.name.text;
+			}
+			if (clause.namedBindings && ts.isNamespaceImport(clause.namedBindings)) {
+				out.namespace = clause.namedBindings.name.text;
+			} else if (clause.namedBindings) {
+				out.named = clause.namedBindings.elements.map(function (e) {
+					return { name: (e.propertyName || e.name).text, alias: e.name.text, isTypeOnly: !!(clause.isTypeOnly || e.isTypeOnly) };
+				});
+			}
+		}
+		imports.push(withLocation(node, out));
+	}
+	function visitExportDeclaration(node) {
+		var moduleSpecifier = node.moduleSpecifier && specifier(node.moduleSpecifier);
+		var isTypeOnly = !!node.isTypeOnly;
+		if (node.moduleSpecifier) {
+			imports.push(withLocation(node, { kind: "export", moduleSpecifier: moduleSpecifier, isTypeOnly: isTypeOnly }));
+		}
+		var clause = node.exportClause;
+		if (!clause) {
+			exports.push(withLocation(node, { name: "*", kind: "reexport", isTypeOnly: isTypeOnly, moduleSpecifier: moduleSpecifier }));
+		} else if (ts.isNamespaceExport && ts.isNamespaceExport(clause)) {
+			exports.push(withLocation(node, { name: clause.name.text, kind: "reexport", isTypeOnly: isTypeOnly, moduleSpecifier: moduleSpecifier }));
+		} else {
+			clause.elements.forEach(function (e) {
+				var name = e.name.text;
+				var localName = (e.propertyName || e.name).text;
+				var out = { name: name, isTypeOnly: isTypeOnly || !!e.isTypeOnly };
+				if (localName !== name) {
+					out.localName = localName;
+				}
+				if (node.moduleSpecifier) {
+					out.kind = "reexport";
+					out.moduleSpecifier = moduleSpecifier;
+				} else {
+					var resolved = resolve(localName);
+					out.kind = resolved.kind;
+					out.isTypeOnly = out.isTypeOnly || resolved.isTypeOnly;
+				}
+				exports.push(withLocation(node, out));
+			});
+		}
+	}
+	function visitExportAssignment(node) {
+		var out = { name: node.isExportEquals ? "export=" : "default", kind: "expression", isTypeOnly: false };
+		if (ts.isIdentifier(node.expression)) {
+			var resolved = resolve(node.expression.text);
+			out.localName = node.expression.text;
+			out.kind = resolved.kind;
+			out.isTypeOnly = resolved.isTypeOnly;
+		}
+		exports.push(withLocation(node, out));
+	}
+	function visitExportedDeclaration(node) {
+		if (!hasModifier(node, SyntaxKind.ExportKeyword)) {
+			return;
+		}
+		// Declarations in ambient modules, such as declare module "mod" { export ... }, aren't exports of this module
+		if (node.parent !== sourceFile) {
+			return;
+		}
+		var isDefault = hasModifier(node, SyntaxKind.DefaultKeyword);
+		if (ts.isVariableStatement(node)) {
+			node.declarationList.declarations.forEach(function (d) {
+				bindingNames(d.name, []).forEach(function (name) {
+					exports.push(withLocation(node, { name: name, kind: "variable", isTypeOnly: false }));
+				});
+			});
+			return;
+		}
+		if (ts.isImportEqualsDeclaration(node)) {
+			exports.push(withLocation(node, { name: node.name.text, kind: "import", isTypeOnly: !!node.isTypeOnly }));
+			return;
+		}
+		var kind = declarationKind(node);
+		if (!kind || node.name && !ts.isIdentifier(node.name)) {
+			return;
+		}
+		var out = { name: isDefault ? "default" : node.name.text, kind: kind[0], isTypeOnly: kind[1] };
+		if (isDefault && node.name) {
+			out.localName = node.name.text;
+		}
+		exports.push(withLocation(node, out));
+	}
+	function visitCall(node) {
+		var argument = node.arguments[0];
+		if (node.expression.kind === SyntaxKind.ImportKeyword) {
+			imports.push(withLocation(node, { kind: "dynamic", moduleSpecifier: specifier(argument), isTypeOnly: false }));
+			return;
+		}
+		if (!ts.isIdentifier(node.expression) || node.expression.text !== "require" || node.arguments.length !== 1) {
+			return;
+		}
+		var out = { kind: "require", moduleSpecifier: specifier(argument), isTypeOnly: false };
+		var parent = node.parent;
+		if (ts.isVariableDeclaration(parent) && parent.initializer === node) {
+			if (ts.isIdentifier(parent.name)) {
+				out.namespace = parent.name.text;
+			} else if (ts.isObjectBindingPattern(parent.name)) {
+				out.named = parent.name.elements.filter(function (e) {
+					return ts.isIdentifier(e.name) && (!e.propertyName || ts.isIdentifier(e.propertyName));
+				}).map(function (e) {
+					return { name: (e.propertyName || e.name).text, alias: e.name.text, isTypeOnly: false };
+				});
+			}
+		}
+		imports.push(withLocation(node, out));
+	}
+
+	function visit(node) {
+		switch (node.kind) {
+			case SyntaxKind.ImportDeclaration:
+				visitImportDeclaration(node);
+				return;
+			case SyntaxKind.ImportEqualsDeclaration:
+				if (ts.isExternalModuleReference(node.moduleReference)) {
+					imports.push(withLocation(node, {
+						kind: "import-equals",
+						moduleSpecifier: specifier(node.moduleReference.expression),
+						namespace: node.name.text,
+						isTypeOnly: !!node.isTypeOnly
+					}));
+				}
+				visitExportedDeclaration(node);
+				return;
+			case SyntaxKind.ExportDeclaration:
+				visitExportDeclaration(node);
+				return;
+			case SyntaxKind.ExportAssignment:
+				visitExportAssignment(node);
+				break;
+			case SyntaxKind.ImportType:
+				imports.push(withLocation(node, { kind: "import-type", moduleSpecifier: specifier(node.argument.literal), isTypeOnly: true }));
+				break;
+			case SyntaxKind.CallExpression:
+				visitCall(node);
+				break;
+			default:
+				visitExportedDeclaration(node);
+		}
+		ts.forEachChild(node, visit);
+	}
+	ts.forEachChild(sourceFile, visit);
+
+	function fileNames(references) {
+		return (references || []).map(function (r) { return r.fileName; });
+	}
+	return JSON.stringify({
+		imports: imports,
+		exports: exports,
+		references: fileNames(preProcessed.referencedFiles),
+		typeReferences: fileNames(preProcessed.typeReferenceDirectives),
+		libReferences: fileNames(preProcessed.libReferenceDirectives),
+		diagnostics: (` + diagnosticsConverter + `)(sourceFile.parseDiagnostics)
+	});
+}`
@@ -0,0 +1,175 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v3_8_3 "github.com/clarkmcc/go-typescript/versions/v3.8.3"
+	v3_9_9 "github.com/clarkmcc/go-typescript/versions/v3.9.9"
+	v4_1_2 "github.com/clarkmcc/go-typescript/versions/v4.1.2"
+	v4_1_3 "github.com/clarkmcc/go-typescript/versions/v4.1.3"
+	v4_1_4 "github.com/clarkmcc/go-typescript/versions/v4.1.4"
+	v4_1_5 "github.com/clarkmcc/go-typescript/versions/v4.1.5"
+	v4_2_2 "github.com/clarkmcc/go-typescript/versions/v4.2.2"
+	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	v4_2_4 "github.com/clarkmcc/go-typescript/versions/v4.2.4"
+	v4_7_2 "github.com/clarkmcc/go-typescript/versions/v4.7.2"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanModule(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	opts := []TranspileOptionFunc{WithRegistry(registry), WithVersion("v4.9.3")}
+
+	script := strings.Join([]string{
+		`/// <reference types="node" />`,
+		`import React, { useState as state, type FC } from "react";`,
+		`import * as path from "path";`,
+		`import "./polyfill";`,
+		`import fs = require("fs");`,
+		`export { readFile } from "fs/promises";`,
+		`export * from "./util";`,
+		`const { join } = require("path");`,
+		`let lazy: import("./lazy").Lazy;`,
+		`export function load() { return import("./plugin"); }`,
+		`export interface Options { debug: boolean }`,
+		`export type Mode = "a" | "b";`,
+		`export const [first, second] = [1, 2];`,
+		`class Widget {}`,
+		`enum Color { Red }`,
+		`export { Widget as Component, Color, FC };`,
+		`export default Widget;`,
+		`declare module "ambient" { export const ignored: number; }`,
+	}, "\n")
+	info, err := ScanModuleString(script, opts...)
+	require.NoError(t, err)
+	require.Empty(t, info.Diagnostics)
+	require.Equal(t, []string{"node"}, info.TypeReferences)
+
+	type importSummary struct {
+		Kind      ImportKind
+		Specifier string
+		Default   string
+		Namespace string
+		Named     []ImportBinding
+		TypeOnly  bool
+	}
+	var imports []importSummary
+	for _, i := range info.Imports {
+		imports = append(imports, importSummary{i.Kind, i.ModuleSpecifier, i.Default, i.Namespace, i.Named, i.IsTypeOnly})
+	}
+	require.Equal(t, []importSummary{
+		{Kind: ImportDeclaration, Specifier: "react", Default: "React", Named: []ImportBinding{
+			{Name: "useState", Alias: "state"},
+			{Name: "FC", Alias: "FC", IsTypeOnly: true},
+		}},
+		{Kind: ImportDeclaration, Specifier: "path", Namespace: "path"},
+		{Kind: ImportDeclaration, Specifier: "./polyfill"},
+		{Kind: ImportEquals, Specifier: "fs", Namespace: "fs"},
+		{Kind: ImportReExport, Specifier: "fs/promises"},
+		{Kind: ImportReExport, Specifier: "./util"},
+		{Kind: ImportRequire, Specifier: "path", Named: []ImportBinding{{Name: "join", Alias: "join"}}},
+		{Kind: ImportType, Specifier: "./lazy", TypeOnly: true},
+		{Kind: ImportDynamic, Specifier: "./plugin"},
+	}, imports)
+
+	type exportSummary struct {
+		Name      string
+		LocalName string
+		Kind      ExportKind
+		TypeOnly  bool
+		Specifier string
+	}
+	var exports []exportSummary
+	for _, e := range info.Exports {
+		exports = append(exports, exportSummary{e.Name, e.LocalName, e.Kind, e.IsTypeOnly, e.ModuleSpecifier})
+	}
+	require.Equal(t, []exportSummary{
+		{Name: "readFile", Kind: ExportReExport, Specifier: "fs/promises"},
+		{Name: "*", Kind: ExportReExport, Specifier: "./util"},
+		{Name: "load", Kind: ExportFunction},
+		{Name: "Options", Kind: ExportInterface, TypeOnly: true},
+		{Name: "Mode", Kind: ExportType, TypeOnly: true},
+		{Name: "first", Kind: ExportVariable},
+		{Name: "second", Kind: ExportVariable},
+		{Name: "Component", LocalName: "Widget", Kind: ExportClass},
+		{Name: "Color", Kind: ExportEnum},
+		{Name: "FC", Kind: ExportImport, TypeOnly: true},
+		{Name: "default", LocalName: "Widget", Kind: ExportClass},
+	}, exports)
+
+	// Positions are those of the whole statement or call
+	dynamic := info.Imports[8]
+	start := strings.Index(script, `import("./plugin")`)
+	require.Equal(t, TextSpan{Start: start, Length: len(`import("./plugin")`)}, dynamic.TextSpan)
+	require.Equal(t, 10, dynamic.Line)
+	require.Equal(t, start-strings.Index(script, "export function load")+1, dynamic.Column)
+	require.Equal(t, "module.ts", dynamic.FileName)
+}
+
+func TestScanModule_Diagnostics(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	opts := []TranspileOptionFunc{WithRegistry(registry), WithVersion("v4.9.3")}
+
+	info, err := ScanModuleString(`import { a from "a";`, opts...)
+	require.NoError(t, err)
+	require.NotEmpty(t, info.Diagnostics)
+
+	_, err = ScanModuleString(`import { a from "a";`, append(opts, WithFailOnDiagnosticErrors())...)
+	var diagnosticsErr *DiagnosticsError
+	require.ErrorAs(t, err, &diagnosticsErr)
+}
+
+func TestScanModule_Versions(t *testing.T) {
+	registry := versions.NewRegistry()
+	sources := map[string]versions.Loader{
+		"v3.8.3": v3_8_3.Source,
+		"v3.9.9": v3_9_9.Source,
+		"v4.1.2": v4_1_2.Source,
+		"v4.1.3": v4_1_3.Source,
+		"v4.1.4": v4_1_4.Source,
+		"v4.1.5": v4_1_5.Source,
+		"v4.2.2": v4_2_2.Source,
+		"v4.2.3": v4_2_3.Source,
+		"v4.2.4": v4_2_4.Source,
+		"v4.7.2": v4_7_2.Source,
+		"v4.9.3": v4_9_3.Source,
+	}
+	for tag, source := range sources {
+		registry.RegisterLoader(tag, source)
+	}
+	script := strings.Join([]string{
+		`import type { A } from "./types";`,
+		`import b, * as c from "b";`,
+		`export * as d from "d";`,
+		`export default function () { return require("e"); }`,
+		`export type { A };`,
+	}, "\n")
+	for tag := range sources {
+		tag := tag
+		t.Run(tag, func(t *testing.T) {
+			info, err := ScanModuleString(script, WithRegistry(registry), WithVersion(tag))
+			require.NoError(t, err)
+			require.Len(t, info.Imports, 4)
+			require.Equal(t, []ImportBinding{{Name: "A", Alias: "A", IsTypeOnly: true}}, info.Imports[0].Named)
+			require.True(t, info.Imports[0].IsTypeOnly)
+			require.Equal(t, "b", info.Imports[1].Default)
+			require.Equal(t, "c", info.Imports[1].Namespace)
+			require.Equal(t, ImportReExport, info.Imports[2].Kind)
+			require.Equal(t, ImportRequire, info.Imports[3].Kind)
+			require.Equal(t, "e", info.Imports[3].ModuleSpecifier)
+
+			require.Len(t, info.Exports, 3)
+			require.Equal(t, "d", info.Exports[0].Name)
+			require.Equal(t, ExportReExport, info.Exports[0].Kind)
+			require.Equal(t, "default", info.Exports[1].Name)
+			require.Equal(t, ExportFunction, info.Exports[1].Kind)
+			require.Equal(t, ExportImport, info.Exports[2].Kind)
+			require.True(t, info.Exports[2].IsTypeOnly)
+		})
+	}
+}
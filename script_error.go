@@ -0,0 +1,83 @@
+package typescript
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// stackFrameRegexp matches a single frame of a goja exception stack trace, for example:
+//
+//	at fail (<eval>:2:11(3))
+//	at <eval>:4:5(12)
+var stackFrameRegexp = regexp.MustCompile(`^at (?:(.*) \()?(.*):(\d+):(\d+)\(\d+\)\)?$`)
+
+// ScriptError is returned by EvaluateCtx when a transpiled script throws an exception. The stack
+// frames of the exception are mapped from the generated javascript back to the typescript source.
+type ScriptError struct {
+	// Exception is the exception thrown by the runtime, its positions refer to the generated javascript.
+	Exception *goja.Exception
+	// Frames is the exception's stack trace, starting with the frame that threw.
+	Frames []StackFrame
+}
+
+// StackFrame is a single frame of a script's stack trace.
+type StackFrame struct {
+	FuncName string
+	// Generated is the position of the frame in the generated javascript.
+	Generated Position
+	// Original is the position of the frame in the typescript source. It is only valid if Mapped is true.
+	Original Position
+	Mapped   bool
+}
+
+func (f StackFrame) String() string {
+	pos := f.Generated
+	if f.Mapped {
+		pos = f.Original
+	}
+	if f.FuncName == "" {
+		return pos.String()
+	}
+	return f.FuncName + " (" + pos.String() + ")"
+}
+
+func (e *ScriptError) Error() string {
+	msg := "<nil>"
+	if v := e.Exception.Value(); v != nil {
+		msg = v.String()
+	}
+	if len(e.Frames) > 0 {
+		msg += " at " + e.Frames[0].String()
+	}
+	return msg
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Exception
+}
+
+// newScriptError maps the stack trace of the exception back to the original source using the source map.
+func newScriptError(exception *goja.Exception, sourceMap *SourceMap) *ScriptError {
+	e := &ScriptError{Exception: exception}
+	for _, line := range strings.Split(exception.String(), "\n") {
+		if !strings.HasPrefix(line, "\tat ") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "\t")
+		m := stackFrameRegexp.FindStringSubmatch(line)
+		if m == nil {
+			// Native frames don't have a position
+			e.Frames = append(e.Frames, StackFrame{FuncName: strings.TrimPrefix(line, "at ")})
+			continue
+		}
+		frame := StackFrame{FuncName: m[1], Generated: Position{Source: m[2]}}
+		frame.Generated.Line, _ = strconv.Atoi(m[3])
+		frame.Generated.Column, _ = strconv.Atoi(m[4])
+		frame.Original, frame.Mapped = sourceMap.OriginalPosition(frame.Generated.Line, frame.Generated.Column)
+		e.Frames = append(e.Frames, frame)
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+package typescript
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-sourcemap/sourcemap"
+)
+
+const sourceMappingURLPrefix = "//# sourceMappingURL="
+
+// SourceMap is a parsed version 3 source map as emitted by the typescript compiler.
+type SourceMap struct {
+	Version        int      `json:"version"`
+	File           string   `json:"file"`
+	SourceRoot     string   `json:"sourceRoot,omitempty"`
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent,omitempty"`
+	Names          []string `json:"names"`
+	Mappings       string   `json:"mappings"`
+
+	// consumer is built lazily the first time a position is looked up
+	consumer *sourcemap.Consumer
+}
+
+// Position is a location in a source file. Lines and columns are 1-based.
+type Position struct {
+	Source string
+	Line   int
+	Column int
+	// Name is the original identifier at this position, if the source map recorded one.
+	Name string
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.Source, p.Line, p.Column)
+}
+
+// ParseSourceMap parses a JSON encoded source map.
+func ParseSourceMap(b []byte) (*SourceMap, error) {
+	var m SourceMap
+	err := json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, fmt.Errorf("decoding source map: %w", err)
+	}
+	m.consumer, err = sourcemap.Parse("", b)
+	if err != nil {
+		return nil, fmt.Errorf("parsing source map mappings: %w", err)
+	}
+	return &m, nil
+}
+
+// OriginalPosition maps the 1-based line and column of the generated javascript back to the
+// position in the original typescript source. It returns false if the source map has no mapping
+// for the generated position. OriginalPosition is not safe for concurrent use until the first call
+// has returned, as the mappings are decoded lazily.
+func (m *SourceMap) OriginalPosition(line, column int) (Position, bool) {
+	if m.consumer == nil {
+		b, err := json.Marshal(m)
+		if err != nil {
+			return Position{}, false
+		}
+		m.consumer, err = sourcemap.Parse("", b)
+		if err != nil {
+			return Position{}, false
+		}
+	}
+	source, name, line, column, ok := m.consumer.Source(line, column-1)
+	if !ok {
+		return Position{}, false
+	}
+	return Position{Source: source, Line: line, Column: column + 1, Name: name}, true
+}
+
+// extractSourceMap splits the sourceMappingURL comment from the end of the transpiled code. If the
+// comment is an inline data URL, the source map encoded in it is returned.
+func extractSourceMap(code string) (string, *SourceMap, error) {
+	idx := strings.LastIndex(code, sourceMappingURLPrefix)
+	if idx < 0 {
+		return code, nil, nil
+	}
+	url := strings.TrimSpace(code[idx+len(sourceMappingURLPrefix):])
+	code = strings.TrimSuffix(code[:idx], "\r\n")
+	if !strings.HasPrefix(url, "data:application/json") {
+		return code, nil, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(url[strings.Index(url, ",")+1:])
+	if err != nil {
+		return code, nil, fmt.Errorf("decoding inline source map: %w", err)
+	}
+	m, err := ParseSourceMap(b)
+	return code, m, err
+}
@@ -0,0 +1,96 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	"github.com/stretchr/testify/require"
+)
+
+var sourceMapScript = strings.TrimSpace(`
+interface Point { x: number }
+let p: Point = { x: 1 };
+function getX(): number {
+  return p.x;
+}
+`)
+
+func TestSourceMap(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+
+	t.Run("conflicting compile option", func(t *testing.T) {
+		result, err := TranspileModuleString(sourceMapScript, WithRegistry(registry), WithVersion("v4.2.3"), WithSourceMap(),
+			WithCompileOptions(map[string]interface{}{"inlineSourceMap": true}), WithFailOnDiagnosticErrors())
+		require.NoError(t, err)
+		require.NotContains(t, result.Code, "sourceMappingURL")
+		require.NotNil(t, result.SourceMap)
+	})
+
+	t.Run("separate source map", func(t *testing.T) {
+		result, err := TranspileModuleString(sourceMapScript, WithRegistry(registry), WithVersion("v4.2.3"), WithSourceMap())
+		require.NoError(t, err)
+		require.NotContains(t, result.Code, "sourceMappingURL")
+		require.NotNil(t, result.SourceMap)
+		require.Equal(t, 3, result.SourceMap.Version)
+		require.Equal(t, []string{"module.ts"}, result.SourceMap.Sources)
+		require.NotEmpty(t, result.SourceMap.Mappings)
+
+		// 'return p.x;' is on line 3 of the generated code, indented by 4 spaces
+		pos, ok := result.SourceMap.OriginalPosition(3, 5)
+		require.True(t, ok)
+		require.Equal(t, Position{Source: "module.ts", Line: 4, Column: 3}, pos)
+	})
+
+	t.Run("inline source map", func(t *testing.T) {
+		result, err := TranspileModuleString(sourceMapScript, WithRegistry(registry), WithVersion("v4.2.3"), WithInlineSourceMap())
+		require.NoError(t, err)
+		require.Contains(t, result.Code, "//# sourceMappingURL=data:application/json;base64,")
+		require.NotNil(t, result.SourceMap)
+		pos, ok := result.SourceMap.OriginalPosition(3, 5)
+		require.True(t, ok)
+		require.Equal(t, 4, pos.Line)
+	})
+
+	t.Run("compile options", func(t *testing.T) {
+		for name, opt := range map[string]TranspileOptionFunc{
+			"map":   WithCompileOptions(map[string]interface{}{"sourceMap": true}),
+			"typed": WithCompilerOptions(CompilerOptions{SourceMap: Bool(true)}),
+		} {
+			result, err := TranspileModuleString(sourceMapScript, WithRegistry(registry), WithVersion("v4.2.3"), opt)
+			require.NoError(t, err, name)
+			require.NotContains(t, result.Code, "sourceMappingURL", name)
+			require.NotNil(t, result.SourceMap, name)
+			require.Equal(t, []string{"module.ts"}, result.SourceMap.Sources, name)
+		}
+		for name, opt := range map[string]TranspileOptionFunc{
+			"map":   WithCompileOptions(map[string]interface{}{"inlineSourceMap": true}),
+			"typed": WithCompilerOptions(CompilerOptions{InlineSourceMap: Bool(true)}),
+		} {
+			result, err := TranspileModuleString(sourceMapScript, WithRegistry(registry), WithVersion("v4.2.3"), opt)
+			require.NoError(t, err, name)
+			require.Contains(t, result.Code, "//# sourceMappingURL=data:application/json;base64,", name)
+			require.NotNil(t, result.SourceMap, name)
+			pos, ok := result.SourceMap.OriginalPosition(3, 5)
+			require.True(t, ok, name)
+			require.Equal(t, 4, pos.Line, name)
+		}
+	})
+
+	t.Run("no source map", func(t *testing.T) {
+		result, err := TranspileModuleString(sourceMapScript, WithRegistry(registry), WithVersion("v4.2.3"))
+		require.NoError(t, err)
+		require.Nil(t, result.SourceMap)
+	})
+
+	t.Run("lazy decoding", func(t *testing.T) {
+		m := &SourceMap{Version: 3, Sources: []string{"a.ts"}, Mappings: "AACA"}
+		pos, ok := m.OriginalPosition(1, 1)
+		require.True(t, ok)
+		require.Equal(t, Position{Source: "a.ts", Line: 2, Column: 1}, pos)
+		_, ok = m.OriginalPosition(2, 1)
+		require.False(t, ok)
+	})
+}
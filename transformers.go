@@ -0,0 +1,179 @@
+package typescript
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Transformers are custom transformers that are run by ts.transpileModule. Each transformer is a javascript
+// expression that evaluates to a ts.TransformerFactory, a function that receives the transformation context
+// and returns a function that transforms a ts.SourceFile, for example:
+//
+//	function (context) {
+//		return function (sourceFile) {
+//			function visit(node) { return ts.visitEachChild(node, visit, context); }
+//			return ts.visitNode(sourceFile, visit);
+//		};
+//	}
+//
+// The expressions are evaluated in the compiler's runtime, so the global ts object is available to them.
+type Transformers struct {
+	// Before are run before the built-in typescript transformers, while the source file is still typescript.
+	Before []string `json:"before,omitempty"`
+	// After are run after the built-in typescript transformers, on the javascript that is emitted.
+	After []string `json:"after,omitempty"`
+}
+
+// WithTransformers adds custom transformers that are run when transpiling.
+func WithTransformers(transformers Transformers) TranspileOptionFunc {
+	return func(config *Config) {
+		before, after := config.Transformers.Before, config.Transformers.After
+		config.Transformers.Before = append(before[:len(before):len(before)], transformers.Before...)
+		config.Transformers.After = append(after[:len(after):len(after)], transformers.After...)
+	}
+}
+
+// NodeAction is the result of visiting a node, see KeepNode, RemoveNode and ReplaceNode.
+type NodeAction struct {
+	remove  bool
+	replace bool
+	text    string
+}
+
+// KeepNode keeps the visited node and continues by visiting its children.
+var KeepNode = NodeAction{}
+
+// RemoveNode removes the visited node. Only nodes in a list, such as statements, parameters or arguments,
+// can be removed.
+var RemoveNode = NodeAction{remove: true}
+
+// ReplaceNode replaces the visited node with the typescript source in text. Statements can be replaced with any
+// number of statements, and expressions, including identifiers, can be replaced with a single expression.
+func ReplaceNode(text string) NodeAction {
+	return NodeAction{replace: true, text: text}
+}
+
+// Visitor is called for every node in the syntax tree of the script being transpiled, in depth-first order.
+// The children of removed and replaced nodes are not visited.
+type Visitor func(node *Node) NodeAction
+
+// WithVisitor adds a visitor that can remove or replace nodes in the script before it is transpiled. The
+// visitors run before any other transformers, and are called in the order that they are added, with the first
+// visitor that doesn't keep a node deciding what happens to it. Results are never cached when there are
+// visitors, since a visitor's behavior can't be included in the cache key.
+func WithVisitor(visitor Visitor) TranspileOptionFunc {
+	return func(config *Config) {
+		config.Visitors = append(config.Visitors[:len(config.Visitors):len(config.Visitors)], visitor)
+	}
+}
+
+// nodeEdit is the action taken on a node, encoded for the nodeEditTransformer.
+type nodeEdit struct {
+	Remove bool   `json:"remove,omitempty"`
+	Text   string `json:"text,omitempty"`
+}
+
+// transformersExpression returns the javascript expression for the transformers passed to transpileModule,
+// calling the config's visitors to determine the edits to make to the script.
+func transformersExpression(cfg *Config, script []byte, fileName string) (string, error) {
+	if len(cfg.Visitors) == 0 && len(cfg.Transformers.Before) == 0 && len(cfg.Transformers.After) == 0 {
+		return "undefined", nil
+	}
+	var before []string
+	if len(cfg.Visitors) > 0 {
+		file, err := parseSourceFile(cfg, script, fileName)
+		if err != nil {
+			return "", err
+		}
+		edits := make(map[int]nodeEdit)
+		visitNodes(cfg.Visitors, file.Statements, edits)
+		if len(edits) > 0 {
+			b, err := json.Marshal(edits)
+			if err != nil {
+				return "", fmt.Errorf("marshalling node edits: %w", err)
+			}
+			before = append(before, fmt.Sprintf("(%s)(%s)", nodeEditTransformer, b))
+		}
+	}
+	for _, t := range cfg.Transformers.Before {
+		before = append(before, "("+t+")")
+	}
+	after := make([]string, len(cfg.Transformers.After))
+	for i, t := range cfg.Transformers.After {
+		after[i] = "(" + t + ")"
+	}
+	return fmt.Sprintf("{ before: [%s], after: [%s] }", strings.Join(before, ", "), strings.Join(after, ", ")), nil
+}
+
+// visitNodes calls the visitors for each of the nodes and their children, recording the edits by node index.
+func visitNodes(visitors []Visitor, nodes []*Node, edits map[int]nodeEdit) {
+	for _, node := range nodes {
+		action := KeepNode
+		for _, visitor := range visitors {
+			action = visitor(node)
+			if action != KeepNode {
+				break
+			}
+		}
+		switch {
+		case action.remove:
+			edits[node.index] = nodeEdit{Remove: true}
+		case action.replace:
+			edits[node.index] = nodeEdit{Text: action.text}
+		default:
+			visitNodes(visitors, node.Children, edits)
+		}
+	}
+}
+
+// nodeEditTransformer is a javascript function expression that returns a transformer factory which applies
+// the edits made by visitors. Nodes are identified by their index in a depth-first walk of the source file
+// with ts.forEachChild, which is the same walk used to create the nodes passed to the visitors.
+const nodeEditTransformer = `function (edits) {
+	function parse(text, replacement) {
+		var sourceFile = ts.createSourceFile("replacement.ts", text, ts.ScriptTarget.Latest, true);
+		if (sourceFile.parseDiagnostics.length > 0) {
+			throw new SyntaxError("invalid replacement '" + replacement + "': " +
+				ts.flattenDiagnosticMessageText(sourceFile.parseDiagnostics[0].messageText, "\n"));
+		}
+		return sourceFile;
+	}
+	function isStatementList(node) {
+		return ts.isSourceFile(node) || ts.isBlock(node) || ts.isModuleBlock(node) || ts.isCaseClause(node) || ts.isDefaultClause(node);
+	}
+	function replacement(node, parent, text) {
+		if (isStatementList(parent)) {
+			return parse(text, text).statements.map(function (statement) { return ts.getSynthesizedDeepClone(statement); });
+		}
+		if (ts.isExpression(node)) {
+			return ts.getSynthesizedDeepClone(parse("(" + text + ")", text).statements[0].expression.expression);
+		}
+		throw new TypeError("cannot replace a node of kind " + ts.SyntaxKind[node.kind] + ", only statements and expressions can be replaced");
+	}
+	return function (context) {
+		return function (sourceFile) {
+			var indexes = new Map();
+			var parents = new Map();
+			function index(parent) {
+				return function (node) {
+					indexes.set(node, indexes.size);
+					parents.set(node, parent);
+					ts.forEachChild(node, index(node));
+				};
+			}
+			ts.forEachChild(sourceFile, index(sourceFile));
+			function visit(node) {
+				var edit = edits[indexes.get(node)];
+				if (!edit) {
+					return ts.visitEachChild(node, visit, context);
+				}
+				if (edit.remove) {
+					return undefined;
+				}
+				return replacement(node, parents.get(node), edit.text);
+			}
+			return ts.visitEachChild(sourceFile, visit, context);
+		};
+	};
+}`
@@ -0,0 +1,95 @@
+package typescript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformers(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+	opts := []TranspileOptionFunc{WithRegistry(registry), WithVersion("v4.9.3")}
+
+	t.Run("javascript transformers", func(t *testing.T) {
+		// Renames every identifier called foo to bar
+		rename := `function (context) {
+			return function (sourceFile) {
+				function visit(node) {
+					if (ts.isIdentifier(node) && node.text === "foo") {
+						return ts.factory.createIdentifier("bar");
+					}
+					return ts.visitEachChild(node, visit, context);
+				}
+				return ts.visitNode(sourceFile, visit);
+			};
+		}`
+		// Adds a comment to the start of the emitted javascript
+		banner := `function (context) {
+			return function (sourceFile) {
+				return ts.addSyntheticLeadingComment(sourceFile.statements[0], ts.SyntaxKind.MultiLineCommentTrivia, " generated ", true) && sourceFile;
+			};
+		}`
+		output, err := TranspileString("let foo: number = 10;", append(opts,
+			WithTransformers(Transformers{Before: []string{rename}, After: []string{banner}}))...)
+		require.NoError(t, err)
+		require.Equal(t, "/* generated */\r\nvar bar = 10;", output)
+	})
+
+	t.Run("visitor", func(t *testing.T) {
+		script := strings.Join([]string{
+			"console.debug('starting');",
+			"function run(n: number) {",
+			"  console.debug('running', n);",
+			"  return track(n * 2);",
+			"}",
+		}, "\n")
+		var kinds []string
+		output, err := TranspileString(script, append(opts,
+			WithVisitor(func(node *Node) NodeAction {
+				kinds = append(kinds, node.Kind)
+				return KeepNode
+			}),
+			WithVisitor(func(node *Node) NodeAction {
+				if node.Kind == "ExpressionStatement" && strings.HasPrefix(node.Text, "console.debug(") {
+					return RemoveNode
+				}
+				if node.Kind == "Identifier" && node.Text == "track" {
+					require.Equal(t, "CallExpression", node.Parent.Kind)
+					return ReplaceNode("metrics.track")
+				}
+				return KeepNode
+			}))...)
+		require.NoError(t, err)
+		require.Equal(t, "function run(n) {\r\n    return metrics.track(n * 2);\r\n}", output)
+		// The children of the removed statement are not visited
+		require.Equal(t, []string{"ExpressionStatement", "FunctionDeclaration", "Identifier", "Parameter"}, kinds[:4])
+	})
+
+	t.Run("replace statement", func(t *testing.T) {
+		output, err := TranspileString("let a = 1;\nlet b = 2;", append(opts,
+			WithVisitor(func(node *Node) NodeAction {
+				if node.Kind == "VariableStatement" && node.Start == 0 {
+					return ReplaceNode("const x = 1; const y = 2;")
+				}
+				return KeepNode
+			}))...)
+		require.NoError(t, err)
+		require.Equal(t, "var x = 1;\r\nvar y = 2;\r\nvar b = 2;", output)
+	})
+
+	t.Run("invalid replacement", func(t *testing.T) {
+		_, err := TranspileString("let a = 1;", append(opts,
+			WithVisitor(func(node *Node) NodeAction {
+				if node.Kind == "NumericLiteral" {
+					return ReplaceNode("1 +")
+				}
+				return KeepNode
+			}))...)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid replacement '1 +'")
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"log"
 	"strings"
@@ -24,43 +25,231 @@ func TranspileString(script string, opts ...TranspileOptionFunc) (string, error)
 // TranspileCtx compiles the bytes read from script using the provided context. Note that due to a limitation
 // in goja, context cancellation only works while in JavaScript code, it does not interrupt native Go functions.
 func TranspileCtx(ctx context.Context, script io.Reader, opts ...TranspileOptionFunc) (string, error) {
+	result, err := TranspileModuleCtx(ctx, script, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.Code, nil
+}
+
+// TranspileResult is the output of transpiling a single typescript module.
+type TranspileResult struct {
+	// Code is the transpiled javascript.
+	Code string `json:"code"`
+	// Diagnostics are the syntactic diagnostics reported by the compiler while transpiling. Note that
+	// transpiling does not type-check, so semantic errors are never reported here.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	// SourceMap is the source map for the transpiled code, only set if source maps were requested
+	// with WithSourceMap or WithInlineSourceMap.
+	SourceMap *SourceMap `json:"sourceMap,omitempty"`
+}
+
+// TranspileModule transpiles the bytes read from reader and returns the transpiled code along with
+// any diagnostics reported by the compiler.
+func TranspileModule(reader io.Reader, opts ...TranspileOptionFunc) (*TranspileResult, error) {
+	return TranspileModuleCtx(context.Background(), reader, opts...)
+}
+
+// TranspileModuleString transpiles the provided typescript string and returns the transpiled code
+// along with any diagnostics reported by the compiler.
+func TranspileModuleString(script string, opts ...TranspileOptionFunc) (*TranspileResult, error) {
+	return TranspileModuleCtx(context.Background(), strings.NewReader(script), opts...)
+}
+
+// TranspileModuleCtx transpiles the bytes read from script using ts.transpileModule and returns the
+// transpiled code along with any diagnostics reported by the compiler. If the config has
+// FailOnDiagnosticErrors set and the compiler reported any errors, a *DiagnosticsError is returned.
+// If script has a Name method, such as an *os.File, the name is used as the default file name.
+func TranspileModuleCtx(ctx context.Context, script io.Reader, opts ...TranspileOptionFunc) (*TranspileResult, error) {
+	scriptBytes, err := ioutil.ReadAll(script)
+	if err != nil {
+		return nil, fmt.Errorf("reading script from reader: %w", err)
+	}
 	cfg := NewDefaultConfig()
+	cfg.FileName = readerName(script)
 	for _, fn := range opts {
 		fn(cfg)
 	}
+	return transpile(cfg, scriptBytes, func() (func(), error) {
+		return loadCompiler(ctx, cfg)
+	})
+}
+
+// TranspileFile transpiles the file at name in fsys, using name as the file name passed to the compiler.
+func TranspileFile(ctx context.Context, fsys fs.FS, name string, opts ...TranspileOptionFunc) (*TranspileResult, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening script: %w", err)
+	}
+	defer f.Close()
+	return TranspileModuleCtx(ctx, f, append([]TranspileOptionFunc{WithFileName(name)}, opts...)...)
+}
+
+// readerName returns the name of the reader if it has one, for example the path of an *os.File.
+func readerName(r io.Reader) string {
+	if n, ok := r.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return ""
+}
+
+// loadCompiler initializes the config and loads the typescript compiler into the config's runtime. The
+// returned function must be called once the caller is done using the runtime.
+func loadCompiler(ctx context.Context, cfg *Config) (func(), error) {
 	// Handle context cancellation
+	done := func() {}
 	if !cfg.PreventCancellation {
-		done := startInterruptable(ctx, cfg.Runtime)
-		defer close(done)
+		interrupted := startInterruptable(ctx, cfg.Runtime)
+		done = func() { close(interrupted) }
 	}
 	err := cfg.Initialize()
 	if err != nil {
-		return "", fmt.Errorf("initializing config: %w", err)
+		done()
+		return nil, fmt.Errorf("initializing config: %w", err)
+	}
+	err = cfg.resolveVersion()
+	if err != nil {
+		done()
+		return nil, fmt.Errorf("getting typescript source: %w", err)
 	}
 	src, err := cfg.Registry.Get(cfg.TypescriptVersion)
 	if err != nil {
-		return "", fmt.Errorf("getting typescript source: %w", err)
+		done()
+		return nil, fmt.Errorf("getting typescript source: %w", err)
 	}
 	_, err = cfg.Runtime.RunProgram(src)
 	if err != nil {
-		return "", fmt.Errorf("running typescript compiler: %w", err)
+		done()
+		return nil, fmt.Errorf("running typescript compiler: %w", err)
+	}
+	cfg.checkedOptions = make(map[string]error)
+	return done, nil
+}
+
+// newCompilerConfig creates a config from the provided options and loads the typescript compiler into the
+// config's runtime. The returned function must be called once the caller is done using the runtime.
+func newCompilerConfig(ctx context.Context, opts []TranspileOptionFunc) (*Config, func(), error) {
+	cfg := NewDefaultConfig()
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	done, err := loadCompiler(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, done, nil
+}
+
+// transpile returns the result from the config's cache if there is one, otherwise it calls load to prepare
+// the config's runtime and transpiles the script. The function returned by load is called once the script
+// is transpiled.
+func transpile(cfg *Config, script []byte, load func() (func(), error)) (*TranspileResult, error) {
+	var key string
+	cache := cfg.Cache != nil && len(cfg.Visitors) == 0
+	if cache {
+		// The cache key uses the resolved version, so that results are never reused across versions that
+		// satisfy the same constraint. A version that can't be resolved is reported when loading the compiler.
+		_ = cfg.resolveVersion()
+		var err error
+		key, err = cacheKey(cfg, script)
+		if err != nil {
+			return nil, err
+		}
+		result, ok, err := getCachedResult(cfg.Cache, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return checkDiagnostics(cfg, result)
+		}
+	}
+	done, err := load()
+	if err != nil {
+		return nil, err
 	}
-	optionBytes, err := json.Marshal(cfg.CompileOptions)
+	result, err := transpileModule(cfg, script)
+	done()
 	if err != nil {
-		return "", fmt.Errorf("marshalling compile options: %w", err)
+		return nil, err
 	}
-	scriptBytes, err := ioutil.ReadAll(script)
+	if cache {
+		err = setCachedResult(cfg.Cache, key, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return checkDiagnostics(cfg, result)
+}
+
+// checkDiagnostics returns a *DiagnosticsError if the config requires that transpiling fails on errors and
+// the result has error diagnostics.
+func checkDiagnostics(cfg *Config, result *TranspileResult) (*TranspileResult, error) {
+	if cfg.FailOnDiagnosticErrors && hasErrors(result.Diagnostics) {
+		return nil, &DiagnosticsError{Diagnostics: result.Diagnostics}
+	}
+	return result, nil
+}
+
+// transpileModule transpiles the script in the config's runtime. The typescript compiler must
+// already be loaded into the runtime and the config must be initialized.
+func transpileModule(cfg *Config, script []byte) (*TranspileResult, error) {
+	err := checkCompilerOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	optionBytes, err := json.Marshal(cfg.compilerOptions())
+	if err != nil {
+		return nil, fmt.Errorf("marshalling compile options: %w", err)
+	}
+	fileName := "undefined"
+	if cfg.FileName != "" {
+		b, err := json.Marshal(cfg.FileName)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling file name: %w", err)
+		}
+		fileName = string(b)
+	}
+	// transpileModule names the file module.ts, or module.tsx when jsx is enabled, if there's no file name
+	parseFileName := fileName
+	if cfg.FileName == "" {
+		parseFileName = fmt.Sprintf(`(%s || {}).jsx ? "module.tsx" : "module.ts"`, optionBytes)
+	}
+	transformers, err := transformersExpression(cfg, script, parseFileName)
 	if err != nil {
-		return "", fmt.Errorf("reading script from reader: %w", err)
+		return nil, err
 	}
-	s := fmt.Sprintf("ts.transpile(%s('%s'), %s, /*fileName*/ undefined, /*diagnostics*/ undefined, /*moduleName*/ \"%s\")",
-		cfg.decoderName, base64.StdEncoding.EncodeToString(scriptBytes), optionBytes, cfg.ModuleName)
+	s := fmt.Sprintf(`(function () {
+	var output = ts.transpileModule(%s('%s'), { compilerOptions: %s, fileName: %s, reportDiagnostics: true, moduleName: "%s", transformers: %s });
+	return JSON.stringify({ code: output.outputText, sourceMapText: output.sourceMapText, diagnostics: (%s)(output.diagnostics) });
+})()`, cfg.decoderName, base64.StdEncoding.EncodeToString(script), optionBytes, fileName, cfg.ModuleName, transformers, diagnosticsConverter)
 	if cfg.Verbose {
 		log.Println(s)
 	}
 	value, err := cfg.Runtime.RunString(s)
 	if err != nil {
-		return "", fmt.Errorf("running compiler: %w", err)
+		return nil, fmt.Errorf("running compiler: %w", err)
+	}
+	var output struct {
+		TranspileResult
+		SourceMapText string `json:"sourceMapText"`
+	}
+	err = json.Unmarshal([]byte(value.String()), &output)
+	if err != nil {
+		return nil, fmt.Errorf("decoding compiler output: %w", err)
+	}
+	result := output.TranspileResult
+	result.Code = strings.TrimSuffix(result.Code, "\r\n")
+	switch {
+	case cfg.inlineSourceMap():
+		_, result.SourceMap, err = extractSourceMap(result.Code)
+	case output.SourceMapText != "":
+		// The compiler points the sourceMappingURL at a file that doesn't exist, we strip it so that the
+		// code can be evaluated without goja attempting to load the source map from the filesystem.
+		result.Code, _, _ = extractSourceMap(result.Code)
+		result.SourceMap, err = ParseSourceMap([]byte(output.SourceMapText))
+	}
+	if err != nil {
+		return nil, err
 	}
-	return strings.TrimSuffix(value.String(), "\r\n"), nil
+	return &result, nil
 }
@@ -0,0 +1,112 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// ErrTranspilerOption is returned when a per-call option passed to a Transpiler attempts to change
+// the runtime or typescript version that the Transpiler was created with.
+var ErrTranspilerOption = errors.New("the runtime and version of a transpiler cannot be changed")
+
+// Transpiler is a long-lived transpiler that owns a runtime with the typescript compiler already
+// loaded, so repeated calls only pay for the transpilation itself. A Transpiler is safe for concurrent
+// use, but calls are serialized because the underlying goja runtime is not goroutine-safe.
+type Transpiler struct {
+	lock sync.Mutex
+	cfg  *Config
+}
+
+// NewTranspiler calls NewTranspilerCtx using the default background context.
+func NewTranspiler(opts ...TranspileOptionFunc) (*Transpiler, error) {
+	return NewTranspilerCtx(context.Background(), opts...)
+}
+
+// NewTranspilerCtx creates a Transpiler and loads the typescript compiler into its runtime. The provided
+// options are the defaults for every call made with the Transpiler. The context only applies to loading
+// the compiler.
+func NewTranspilerCtx(ctx context.Context, opts ...TranspileOptionFunc) (*Transpiler, error) {
+	cfg, done, err := newCompilerConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	done()
+	cfg.Runtime.ClearInterrupt()
+	return &Transpiler{cfg: cfg}, nil
+}
+
+// Version returns the typescript version tag loaded into the transpiler's runtime.
+func (t *Transpiler) Version() string {
+	return t.cfg.TypescriptVersion
+}
+
+// Transpile calls TranspileCtx using the default background context.
+func (t *Transpiler) Transpile(script io.Reader, opts ...TranspileOptionFunc) (string, error) {
+	return t.TranspileCtx(context.Background(), script, opts...)
+}
+
+// TranspileString transpiles the provided typescript string.
+func (t *Transpiler) TranspileString(script string, opts ...TranspileOptionFunc) (string, error) {
+	return t.TranspileCtx(context.Background(), strings.NewReader(script), opts...)
+}
+
+// TranspileCtx transpiles the bytes read from script and returns the transpiled javascript.
+func (t *Transpiler) TranspileCtx(ctx context.Context, script io.Reader, opts ...TranspileOptionFunc) (string, error) {
+	result, err := t.TranspileModuleCtx(ctx, script, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.Code, nil
+}
+
+// TranspileModuleCtx transpiles the bytes read from script and returns the transpiled code along with any
+// diagnostics reported by the compiler. The provided options are applied on top of the options the
+// Transpiler was created with, and must not change the runtime or typescript version. If script has a Name
+// method, such as an *os.File, the name replaces the file name the Transpiler was created with.
+func (t *Transpiler) TranspileModuleCtx(ctx context.Context, script io.Reader, opts ...TranspileOptionFunc) (*TranspileResult, error) {
+	scriptBytes, err := ioutil.ReadAll(script)
+	if err != nil {
+		return nil, fmt.Errorf("reading script from reader: %w", err)
+	}
+	// The reader's name replaces the transpiler's file name, but only if the reader has one
+	if name := readerName(script); name != "" {
+		opts = append([]TranspileOptionFunc{WithFileName(name)}, opts...)
+	}
+	cfg, err := t.config(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return transpile(cfg, scriptBytes, func() (func(), error) {
+		t.lock.Lock()
+		if cfg.PreventCancellation {
+			return t.lock.Unlock, nil
+		}
+		stop := startResettableInterruptable(ctx, cfg.Runtime)
+		return func() {
+			stop()
+			t.lock.Unlock()
+		}, nil
+	})
+}
+
+// config returns a copy of the transpiler's config with the per-call options applied.
+func (t *Transpiler) config(opts []TranspileOptionFunc) (*Config, error) {
+	cfg := *t.cfg
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.TypescriptVersion != t.cfg.TypescriptVersion {
+		// The version may be a constraint that resolves to the transpiler's version
+		_ = cfg.resolveVersion()
+	}
+	if cfg.Runtime != t.cfg.Runtime || cfg.TypescriptVersion != t.cfg.TypescriptVersion {
+		return nil, ErrTranspilerOption
+	}
+	return &cfg, nil
+}
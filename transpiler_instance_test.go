@@ -0,0 +1,121 @@
+package typescript
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranspiler(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+
+	transpiler, err := NewTranspiler(WithRegistry(registry), WithVersion("v4.2.3"))
+	require.NoError(t, err)
+	require.Equal(t, "v4.2.3", transpiler.Version())
+
+	t.Run("repeated calls", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			output, err := transpiler.TranspileString("let a: number = 10;")
+			require.NoError(t, err)
+			require.Equal(t, "var a = 10;", output)
+		}
+	})
+
+	t.Run("per-call options", func(t *testing.T) {
+		output, err := transpiler.TranspileString("export const a: number = 10;", WithModuleName("myModule"),
+			WithCompileOptions(map[string]interface{}{
+				"module": "amd",
+			}))
+		require.NoError(t, err)
+		require.Contains(t, output, "define(\"myModule\"")
+
+		// The per-call options must not leak into subsequent calls
+		output, err = transpiler.TranspileString("let a: number = 10;")
+		require.NoError(t, err)
+		require.Equal(t, "var a = 10;", output)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		result, err := transpiler.TranspileModuleCtx(context.Background(), strings.NewReader("let a: number = ;"))
+		require.NoError(t, err)
+		require.Len(t, result.Diagnostics, 1)
+	})
+
+	t.Run("immutable version", func(t *testing.T) {
+		_, err := transpiler.TranspileString("let a: number = 10;", WithVersion("v4.9.3"))
+		require.True(t, errors.Is(err, ErrTranspilerOption))
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := transpiler.TranspileCtx(ctx, strings.NewReader("let a: number = 10;"))
+		require.Error(t, err)
+
+		// The runtime must still be usable after a cancellation
+		output, err := transpiler.Transpile(strings.NewReader("let a: number = 10;"))
+		require.NoError(t, err)
+		require.Equal(t, "var a = 10;", output)
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				output, err := transpiler.TranspileString("let a: number = 10;")
+				require.NoError(t, err)
+				require.Equal(t, "var a = 10;", output)
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("configured file name", func(t *testing.T) {
+		transpiler, err := NewTranspiler(WithRegistry(registry), WithVersion("v4.2.3"), WithFileName("configured.ts"),
+			WithSourceMap())
+		require.NoError(t, err)
+		result, err := transpiler.TranspileModuleCtx(context.Background(), strings.NewReader("let a: number = ;"))
+		require.NoError(t, err)
+		require.Equal(t, "configured.ts", result.Diagnostics[0].File)
+		require.Equal(t, []string{"configured.ts"}, result.SourceMap.Sources)
+
+		// A reader with a name replaces the configured file name
+		result, err = transpiler.TranspileModuleCtx(context.Background(), namedReader{strings.NewReader("let a = 10;"), "named.ts"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"named.ts"}, result.SourceMap.Sources)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		_, err := NewTranspiler(WithRegistry(registry), WithVersion("v0.0.0"))
+		require.Error(t, err)
+	})
+}
+
+// namedReader is a reader with a Name method, like *os.File.
+type namedReader struct {
+	*strings.Reader
+	name string
+}
+
+func (r namedReader) Name() string { return r.name }
+
+func BenchmarkTranspiler(b *testing.B) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+	transpiler, err := NewTranspiler(WithRegistry(registry), WithVersion("v4.2.3"))
+	require.NoError(b, err)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err = transpiler.TranspileString("let a: number = 10;")
+		require.NoError(b, err)
+	}
+}
@@ -2,18 +2,22 @@ package typescript
 
 import (
 	"context"
+	"errors"
 	"github.com/clarkmcc/go-typescript/versions"
 	v4_2_3 "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
 	"github.com/dop251/goja"
 	"github.com/stretchr/testify/require"
+	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestCompileVariousScripts(t *testing.T) {
 	runtime := goja.New()
 	registry := versions.NewRegistry()
-	registry.Register("v4.2.3", v4_2_3.Source)
+	v4_2_3.Register(registry)
 
 	t.Run("let", func(t *testing.T) {
 		compiled, err := TranspileString("let a: number = 10;", WithCompileOptions(map[string]interface{}{
@@ -32,12 +36,42 @@ func TestCompileVariousScripts(t *testing.T) {
 	})
 }
 
-//func TestCompileErrors(t *testing.T) {
-//	t.Run("bad syntax", func(t *testing.T) {
-//		_, err := TranspileString("asdjaksdhkjasd")
-//		require.Error(t, err)
-//	})
-//}
+func TestCompileErrors(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_2_3.Register(registry)
+
+	t.Run("diagnostics", func(t *testing.T) {
+		result, err := TranspileModuleString("let a: number = ;", WithVersion("v4.2.3"), WithRegistry(registry))
+		require.NoError(t, err)
+		require.Len(t, result.Diagnostics, 1)
+		d := result.Diagnostics[0]
+		require.Equal(t, 1109, d.Code)
+		require.Equal(t, DiagnosticCategoryError, d.Category)
+		require.Equal(t, "Expression expected.", d.Message)
+		require.Equal(t, "module.ts", d.File)
+		require.Equal(t, 1, d.Line)
+		require.Equal(t, 17, d.Column)
+		require.Equal(t, 16, d.Start)
+		require.Equal(t, 1, d.Length)
+	})
+
+	t.Run("fail on errors", func(t *testing.T) {
+		_, err := TranspileString("let a: number = ;", WithVersion("v4.2.3"), WithRegistry(registry),
+			WithFailOnDiagnosticErrors())
+		var diagnosticsErr *DiagnosticsError
+		require.True(t, errors.As(err, &diagnosticsErr))
+		require.Len(t, diagnosticsErr.Diagnostics, 1)
+		require.Contains(t, err.Error(), "module.ts(1,17): error TS1109: Expression expected.")
+	})
+
+	t.Run("valid script", func(t *testing.T) {
+		result, err := TranspileModuleString("let a: number = 10;", WithVersion("v4.2.3"), WithRegistry(registry),
+			WithFailOnDiagnosticErrors())
+		require.NoError(t, err)
+		require.Empty(t, result.Diagnostics)
+		require.Equal(t, "var a = 10;", result.Code)
+	})
+}
 
 func TestCancelContext(t *testing.T) {
 	runtime := goja.New()
@@ -54,8 +88,73 @@ func TestBadConfig(t *testing.T) {
 
 func TestTranspile(t *testing.T) {
 	registry := versions.NewRegistry()
-	registry.Register("v4.2.3", v4_2_3.Source)
+	v4_2_3.Register(registry)
 	output, err := Transpile(strings.NewReader("let a: number = 10;"), WithRegistry(registry), WithVersion("v4.2.3"))
 	require.NoError(t, err)
 	require.Equal(t, "var a = 10;", output)
 }
+
+func TestTranspileDefaultConfig(t *testing.T) {
+	output, err := TranspileString("let a: number = 10;")
+	require.NoError(t, err)
+	require.Equal(t, "var a = 10;", output)
+
+	// Constraints are resolved against the versions in the default registry
+	output, err = TranspileString("let a: number = 10;", WithVersion("latest"))
+	require.NoError(t, err)
+	require.Equal(t, "var a = 10;", output)
+}
+
+func TestFileNames(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+
+	t.Run("tsx", func(t *testing.T) {
+		script := "const el = <div className=\"a\">hello</div>;"
+		result, err := TranspileModuleString(script, WithRegistry(registry), WithVersion("v4.9.3"),
+			WithFileName("component.tsx"), WithCompileOptions(map[string]interface{}{"jsx": "react"}))
+		require.NoError(t, err)
+		require.Empty(t, result.Diagnostics)
+		require.Equal(t, "var el = React.createElement(\"div\", { className: \"a\" }, \"hello\");", result.Code)
+
+		// The same script is invalid if the compiler doesn't know it's a tsx file
+		result, err = TranspileModuleString(script, WithRegistry(registry), WithVersion("v4.9.3"),
+			WithFileName("component.ts"))
+		require.NoError(t, err)
+		require.NotEmpty(t, result.Diagnostics)
+		require.Equal(t, "component.ts", result.Diagnostics[0].File)
+	})
+
+	t.Run("module format", func(t *testing.T) {
+		opts := []TranspileOptionFunc{WithRegistry(registry), WithVersion("v4.9.3"),
+			WithCompileOptions(map[string]interface{}{"module": "node16"})}
+		output, err := TranspileString("export const a: number = 10;", append(opts, WithFileName("module.mts"))...)
+		require.NoError(t, err)
+		require.Equal(t, "export var a = 10;", output)
+		output, err = TranspileString("export const a: number = 10;", append(opts, WithFileName("module.cts"))...)
+		require.NoError(t, err)
+		require.Contains(t, output, "exports.a = 10;")
+	})
+
+	t.Run("named reader", func(t *testing.T) {
+		f, err := os.CreateTemp(t.TempDir(), "*.ts")
+		require.NoError(t, err)
+		_, err = f.WriteString("let a: number = ;")
+		require.NoError(t, err)
+		_, err = f.Seek(0, 0)
+		require.NoError(t, err)
+		defer f.Close()
+		result, err := TranspileModule(f, WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.Equal(t, f.Name(), result.Diagnostics[0].File)
+	})
+
+	t.Run("file system", func(t *testing.T) {
+		result, err := TranspileFile(context.Background(), fstest.MapFS{
+			"src/a.ts": {Data: []byte("let a: number = ;")},
+		}, "src/a.ts", WithRegistry(registry), WithVersion("v4.9.3"), WithSourceMap())
+		require.NoError(t, err)
+		require.Equal(t, "src/a.ts", result.Diagnostics[0].File)
+		require.Equal(t, []string{"a.ts"}, result.SourceMap.Sources)
+	})
+}
@@ -0,0 +1,88 @@
+package typescript
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/dop251/goja"
+)
+
+// TSConfig is a parsed tsconfig.json file.
+type TSConfig struct {
+	// FileNames are the absolute paths of the files matched by the files, include and exclude properties.
+	FileNames []string `json:"fileNames"`
+	// CompileOptions are the compiler options after resolving the extends chain. Enum values are converted
+	// to the numeric values used by the compiler. These can be passed directly to WithCompileOptions.
+	CompileOptions map[string]interface{} `json:"options"`
+	// Diagnostics are the errors found while reading and parsing the config file.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// LoadTSConfig calls LoadTSConfigCtx using the default background context.
+func LoadTSConfig(fsys fs.FS, path string, opts ...TranspileOptionFunc) (*TSConfig, error) {
+	return LoadTSConfigCtx(context.Background(), fsys, path, opts...)
+}
+
+// LoadTSConfigCtx reads and parses the tsconfig.json file at path in fsys using ts.parseJsonConfigFileContent.
+// The extends chain is resolved and the files, include and exclude globs are expanded against fsys. Errors in
+// the config are returned as diagnostics, unless FailOnDiagnosticErrors is set in which case a *DiagnosticsError
+// is returned.
+func LoadTSConfigCtx(ctx context.Context, fsys fs.FS, path string, opts ...TranspileOptionFunc) (*TSConfig, error) {
+	cfg, done, err := newCompilerConfig(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	pathBytes, err := json.Marshal(compilerPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling config path: %w", err)
+	}
+	s := fmt.Sprintf(`(function (host) {
+	var configPath = %s;
+	var sourceFile = ts.readJsonConfigFile(configPath, host.readFile);
+	if (!sourceFile.statements) {
+		// The config file could not be read
+		return JSON.stringify({ fileNames: [], options: {}, diagnostics: (%s)(sourceFile.parseDiagnostics) });
+	}
+	var parseHost = {
+		useCaseSensitiveFileNames: true,
+		readDirectory: function (rootDir, extensions, excludes, includes, depth) {
+			return ts.matchFiles(rootDir, extensions, excludes, includes, true, "/", depth, host.getFileSystemEntries, function (path) { return path; });
+		},
+		fileExists: host.fileExists,
+		readFile: host.readFile
+	};
+	var parsed = ts.parseJsonSourceFileConfigFileContent(sourceFile, parseHost, ts.getDirectoryPath(configPath), undefined, configPath);
+	// The parsed source file is attached to the options, it can't be serialized and isn't a compile option
+	delete parsed.options.configFile;
+	var diagnostics = sourceFile.parseDiagnostics.concat(parsed.errors);
+	return JSON.stringify({ fileNames: parsed.fileNames, options: parsed.options, diagnostics: (%s)(diagnostics) });
+})`, pathBytes, diagnosticsConverter, diagnosticsConverter)
+	if cfg.Verbose {
+		log.Println(s)
+	}
+	value, err := cfg.Runtime.RunString(s)
+	if err != nil {
+		return nil, fmt.Errorf("creating config parser: %w", err)
+	}
+	fn, ok := goja.AssertFunction(value)
+	if !ok {
+		return nil, fmt.Errorf("config parser is not a function")
+	}
+	value, err = fn(goja.Undefined(), newFSHost(cfg, fsys).object())
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	var result TSConfig
+	err = json.Unmarshal([]byte(value.String()), &result)
+	if err != nil {
+		return nil, fmt.Errorf("decoding parsed config: %w", err)
+	}
+	if cfg.FailOnDiagnosticErrors && hasErrors(result.Diagnostics) {
+		return nil, &DiagnosticsError{Diagnostics: result.Diagnostics}
+	}
+	return &result, nil
+}
@@ -0,0 +1,63 @@
+package typescript
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	v4_9_3 "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTSConfig(t *testing.T) {
+	registry := versions.NewRegistry()
+	v4_9_3.Register(registry)
+
+	fsys := fstest.MapFS{
+		"base/tsconfig.base.json": {Data: []byte(`{ "compilerOptions": { "target": "es2015", "strict": true } }`)},
+		"tsconfig.json": {Data: []byte(`{
+			// comments are allowed in tsconfig files
+			"extends": "./base/tsconfig.base.json",
+			"compilerOptions": { "module": "amd" },
+			"include": ["src/**/*"],
+			"exclude": ["src/**/*.test.ts"]
+		}`)},
+		"invalid.json":  {Data: []byte(`{ "compilerOptions": { "modlue": "amd" }, "files": ["src/a.ts"] }`)},
+		"src/a.ts":      {Data: []byte("export const a: number = 10;")},
+		"src/b/c.ts":    {Data: []byte("export const c: number = 10;")},
+		"src/a.test.ts": {Data: []byte("export const test: number = 10;")},
+		"other/d.ts":    {Data: []byte("export const d: number = 10;")},
+	}
+
+	t.Run("extends and globs", func(t *testing.T) {
+		tsconfig, err := LoadTSConfig(fsys, "tsconfig.json", WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.Empty(t, tsconfig.Diagnostics)
+		require.Equal(t, []string{"/src/a.ts", "/src/b/c.ts"}, tsconfig.FileNames)
+		require.Equal(t, float64(2), tsconfig.CompileOptions["target"])
+		require.Equal(t, float64(2), tsconfig.CompileOptions["module"])
+		require.Equal(t, true, tsconfig.CompileOptions["strict"])
+
+		output, err := TranspileString("export const a: number = 10;", WithTSConfig(tsconfig),
+			WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.Contains(t, output, "define(\"default\", [\"require\", \"exports\"]")
+		require.Contains(t, output, "exports.a = 10;")
+	})
+
+	t.Run("invalid option", func(t *testing.T) {
+		tsconfig, err := LoadTSConfig(fsys, "invalid.json", WithRegistry(registry), WithVersion("v4.9.3"))
+		require.NoError(t, err)
+		require.Len(t, tsconfig.Diagnostics, 1)
+		require.Equal(t, 5025, tsconfig.Diagnostics[0].Code)
+		require.Equal(t, "/invalid.json", tsconfig.Diagnostics[0].File)
+		require.Equal(t, []string{"/src/a.ts"}, tsconfig.FileNames)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadTSConfig(fsys, "missing.json", WithRegistry(registry), WithVersion("v4.9.3"),
+			WithFailOnDiagnosticErrors())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error TS5083")
+	})
+}
@@ -0,0 +1,17 @@
+// Package all imports every bundled version of the typescript compiler, registering each of them in
+// versions.DefaultRegistry.
+package all
+
+import (
+	_ "github.com/clarkmcc/go-typescript/versions/v3.8.3"
+	_ "github.com/clarkmcc/go-typescript/versions/v3.9.9"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.1.2"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.1.3"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.1.4"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.1.5"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.2.2"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.2.3"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.2.4"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.7.2"
+	_ "github.com/clarkmcc/go-typescript/versions/v4.9.3"
+)
@@ -0,0 +1,20 @@
+package all
+
+import (
+	"testing"
+
+	"github.com/clarkmcc/go-typescript/versions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRegistry(t *testing.T) {
+	var tags []string
+	for _, v := range versions.DefaultRegistry.RegisteredVersions() {
+		tags = append(tags, v.Tag)
+	}
+	require.Equal(t, []string{"v3.8.3", "v3.9.9", "v4.1.2", "v4.1.3", "v4.1.4", "v4.1.5", "v4.2.2", "v4.2.3", "v4.2.4", "v4.7.2", "v4.9.3"}, tags)
+
+	tag, err := versions.DefaultRegistry.Resolve("latest")
+	require.NoError(t, err)
+	require.Equal(t, "v4.9.3", tag)
+}
@@ -3,6 +3,7 @@ package versions
 import (
 	"fmt"
 	"github.com/dop251/goja"
+	"io/fs"
 	"sync"
 	"time"
 )
@@ -13,8 +14,10 @@ import (
 // compiled program to stay cached for longer.
 type ExpiringRegistry struct {
 	lock     sync.Mutex
-	versions map[string]string
+	versions map[string]Loader
 	compiled map[string]entry
+	pending  map[string]*pendingProgram
+	libs     map[string]fs.FS
 
 	// A struct is sent on this channel every time the registry is cleaned up.
 	Freed chan struct{}
@@ -30,44 +33,114 @@ type entry struct {
 func (r *ExpiringRegistry) Register(tag string, source string) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	r.versions[tag] = source
+	r.versions[tag] = sourceLoader(source)
+	delete(r.pending, tag)
 }
 
-func (r *ExpiringRegistry) Get(tag string) (*goja.Program, error) {
+// RegisterLoader registers the loader to the specified tag. The loader is called each time the version is
+// compiled, which is again after the compiled program expires.
+func (r *ExpiringRegistry) RegisterLoader(tag string, loader Loader) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.versions[tag] = loader
+	delete(r.compiled, tag)
+	delete(r.pending, tag)
+}
+
+func (r *ExpiringRegistry) RegisterLibs(tag string, libs fs.FS) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	r.libs[tag] = libs
+}
 
+func (r *ExpiringRegistry) Libs(tag string) (fs.FS, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	libs, ok := r.libs[tag]
+	if !ok {
+		return nil, fmt.Errorf("no lib files registered for version tag '%s'", tag)
+	}
+	return libs, nil
+}
+
+// Get returns the compiled program for the tag, which may also be a version constraint, see Resolve.
+func (r *ExpiringRegistry) Get(tag string) (*goja.Program, error) {
+	r.lock.Lock()
+	tag, err := r.resolveLocked(tag)
+	if err != nil {
+		r.lock.Unlock()
+		return nil, err
+	}
 	e, ok := r.compiled[tag]
 	if ok && e.exp.After(time.Now()) {
 		e.exp = time.Now().Add(r.ttl)
 		r.compiled[tag] = e
+		r.lock.Unlock()
 		return e.value, nil
 	}
 	delete(r.compiled, tag)
 
-	src, ok := r.versions[tag]
+	// The source is loaded and compiled without holding the lock, see CachingRegistry.Get
+	p, ok := r.pending[tag]
 	if !ok {
-		return nil, fmt.Errorf("unsupported version tag '%s', must be one of %v", tag, r.RegisteredVersions())
+		p = &pendingProgram{done: make(chan struct{})}
+		r.pending[tag] = p
+		loader := r.versions[tag]
+		r.lock.Unlock()
+		p.prg, p.err = compileLoader(tag, loader)
+		r.lock.Lock()
+		if r.pending[tag] == p {
+			delete(r.pending, tag)
+			if p.err == nil {
+				r.compiled[tag] = entry{value: p.prg, exp: time.Now().Add(r.ttl)}
+			}
+		}
+		close(p.done)
+	}
+	r.lock.Unlock()
+	<-p.done
+	return p.prg, p.err
+}
+
+// Resolve returns the tag of the highest registered version that satisfies the constraint, see Resolve.
+func (r *ExpiringRegistry) Resolve(constraint string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.resolveLocked(constraint)
+}
+
+// RegisteredVersions returns the versions that are registered in this registry, in ascending order.
+func (r *ExpiringRegistry) RegisteredVersions() []Version {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.registeredVersionsLocked()
+}
+
+func (r *ExpiringRegistry) resolveLocked(constraint string) (string, error) {
+	if _, ok := r.versions[constraint]; ok {
+		return constraint, nil
 	}
-	prg, err := goja.Compile("", src, true)
+	v, err := Resolve(constraint, r.registeredVersionsLocked())
 	if err != nil {
-		return nil, fmt.Errorf("compiling registered source for tag '%s': %w", tag, err)
+		return "", err
 	}
-	r.compiled[tag] = entry{value: prg, exp: time.Now().Add(r.ttl)}
-	return prg, nil
+	return v.Tag, nil
 }
 
-func (r *ExpiringRegistry) RegisteredVersions() (out []string) {
+func (r *ExpiringRegistry) registeredVersionsLocked() []Version {
+	tags := make([]string, 0, len(r.versions))
 	for k := range r.versions {
-		out = append(out, k)
+		tags = append(tags, k)
 	}
-	return
+	return sortVersions(tags)
 }
 
 func NewExpiringRegistry(ttl time.Duration) *ExpiringRegistry {
 	r := &ExpiringRegistry{
-		versions: make(map[string]string),
+		versions: make(map[string]Loader),
 		compiled: make(map[string]entry),
+		pending:  make(map[string]*pendingProgram),
+		libs:     make(map[string]fs.FS),
 		Freed:    make(chan struct{}),
 		ttl:      ttl,
 	}
@@ -0,0 +1,99 @@
+package versions
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// GzipFS returns a file system that transparently decompresses the gzipped files in fsys. Opening
+// a file opens the file with the same name and an additional ".gz" extension in fsys and returns
+// its decompressed contents. Directory listings have the ".gz" extension stripped from file names.
+func GzipFS(fsys fs.FS) fs.FS {
+	return &gzipFS{fsys: fsys}
+}
+
+type gzipFS struct {
+	fsys fs.FS
+}
+
+func (g *gzipFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	b, err := fs.ReadFile(g.fsys, name+".gz")
+	if err != nil {
+		// Directories are passed through as-is
+		if info, statErr := fs.Stat(g.fsys, name); statErr == nil && info.IsDir() {
+			return g.fsys.Open(name)
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return &gzipFile{Reader: bytes.NewReader(data), info: fileInfo{name: baseName(name), size: int64(len(data))}}, nil
+}
+
+// ReadDir implements fs.ReadDirFS, stripping the ".gz" extension from file names.
+func (g *gzipFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(g.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			out = append(out, e)
+		} else if strings.HasSuffix(e.Name(), ".gz") {
+			out = append(out, gzipDirEntry{DirEntry: e})
+		}
+	}
+	return out, nil
+}
+
+type gzipDirEntry struct {
+	fs.DirEntry
+}
+
+func (e gzipDirEntry) Name() string {
+	return strings.TrimSuffix(e.DirEntry.Name(), ".gz")
+}
+
+func (e gzipDirEntry) Info() (fs.FileInfo, error) {
+	// The decompressed size isn't known without decompressing the file
+	return fileInfo{name: e.Name(), size: -1}, nil
+}
+
+type gzipFile struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *gzipFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *gzipFile) Close() error               { return nil }
+
+type fileInfo struct {
+	name string
+	size int64
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return 0444 }
+func (i fileInfo) ModTime() time.Time { return time.Time{} }
+func (i fileInfo) IsDir() bool        { return false }
+func (i fileInfo) Sys() interface{}   { return nil }
+
+func baseName(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
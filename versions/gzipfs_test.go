@@ -0,0 +1,63 @@
+package versions
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestGzipFS(t *testing.T) {
+	fsys := GzipFS(fstest.MapFS{
+		"lib.d.ts.gz":            {Data: gzipBytes(t, "interface Array<T> {}")},
+		"nested/lib.es5.d.ts.gz": {Data: gzipBytes(t, "interface Object {}")},
+		"invalid.d.ts.gz":        {Data: []byte("not gzipped")},
+	})
+
+	t.Run("ReadFile", func(t *testing.T) {
+		b, err := fs.ReadFile(fsys, "lib.d.ts")
+		require.NoError(t, err)
+		require.Equal(t, "interface Array<T> {}", string(b))
+		b, err = fs.ReadFile(fsys, "nested/lib.es5.d.ts")
+		require.NoError(t, err)
+		require.Equal(t, "interface Object {}", string(b))
+	})
+	t.Run("Stat", func(t *testing.T) {
+		info, err := fs.Stat(fsys, "lib.d.ts")
+		require.NoError(t, err)
+		require.Equal(t, "lib.d.ts", info.Name())
+		require.Equal(t, int64(21), info.Size())
+		info, err = fs.Stat(fsys, "nested")
+		require.NoError(t, err)
+		require.True(t, info.IsDir())
+	})
+	t.Run("ReadDir", func(t *testing.T) {
+		entries, err := fs.ReadDir(fsys, ".")
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		require.Equal(t, []string{"invalid.d.ts", "lib.d.ts", "nested"}, names)
+	})
+	t.Run("NotExist", func(t *testing.T) {
+		_, err := fs.ReadFile(fsys, "lib.dom.d.ts")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := fs.ReadFile(fsys, "invalid.d.ts")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,105 @@
+// Command vendorlibs writes the gzipped lib.*.d.ts files of a typescript npm package into a version package,
+// where they are embedded and registered alongside the compiler. It is run by go generate in each of the
+// versions/vX.Y.Z packages:
+//
+//	go generate ./versions/...
+//
+// The package is downloaded from the npm registry unless -archive names a local typescript-x.y.z.tgz.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/clarkmcc/go-typescript/versions"
+)
+
+func main() {
+	tag := flag.String("tag", "", "the tag of the version, such as v4.9.3")
+	archive := flag.String("archive", "", "a local typescript npm package archive to read instead of downloading it")
+	dir := flag.String("dir", ".", "the directory of the version package")
+	flag.Parse()
+	log.SetPrefix("vendorlibs: ")
+	log.SetFlags(0)
+	if *tag == "" {
+		log.Fatal("-tag is required")
+	}
+	err := vendor(*tag, *archive, *dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// vendor reads the lib files from the package archive for the tag and writes them gzipped to dir.
+func vendor(tag, archive, dir string) error {
+	var r io.Reader
+	if archive != "" {
+		f, err := os.Open(archive)
+		if err != nil {
+			return fmt.Errorf("opening archive: %w", err)
+		}
+		defer f.Close()
+		r = f
+	} else {
+		url := fmt.Sprintf("https://registry.npmjs.org/typescript/-/typescript-%s.tgz", strings.TrimPrefix(tag, "v"))
+		resp, err := http.Get(url)
+		if err != nil {
+			return fmt.Errorf("downloading %s: %w", url, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("downloading %s: %s", url, resp.Status)
+		}
+		r = resp.Body
+	}
+	p, err := versions.ReadNpmArchive(r)
+	if err != nil {
+		return err
+	}
+	if p.Tag != tag {
+		return fmt.Errorf("the archive is for version %s, not %s", p.Tag, tag)
+	}
+	// lib.*.d.ts doesn't match lib.d.ts
+	names, err := fs.Glob(p.Libs, "lib.*")
+	if err != nil {
+		return err
+	}
+	var written int
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".d.ts") {
+			continue
+		}
+		b, err := fs.ReadFile(p.Libs, name)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		if err == nil {
+			err = w.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("compressing %s: %w", name, err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name+".gz"), buf.Bytes(), 0644)
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", name, err)
+		}
+		written++
+	}
+	log.Printf("wrote %d lib files for %s", written, tag)
+	return nil
+}
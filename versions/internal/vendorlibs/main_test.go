@@ -0,0 +1,55 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendor(t *testing.T) {
+	files := map[string]string{
+		"package/package.json":      `{"name": "typescript", "version": "4.9.3"}`,
+		"package/lib/typescript.js": `var ts = { version: "4.9.3" };`,
+		"package/lib/lib.d.ts":      `/// <reference lib="es5" />`,
+		"package/lib/lib.es5.d.ts":  `interface Array<T> {}`,
+	}
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(data))}))
+		_, err := tw.Write([]byte(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	archive := filepath.Join(t.TempDir(), "typescript-4.9.3.tgz")
+	require.NoError(t, os.WriteFile(archive, buf.Bytes(), 0644))
+
+	dir := t.TempDir()
+	require.NoError(t, vendor("v4.9.3", archive, dir))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	require.Equal(t, []string{"lib.d.ts.gz", "lib.es5.d.ts.gz"}, names)
+
+	f, err := os.Open(filepath.Join(dir, "lib.es5.d.ts.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "interface Array<T> {}", string(b))
+
+	require.EqualError(t, vendor("v4.9.4", archive, t.TempDir()), "the archive is for version v4.9.3, not v4.9.4")
+}
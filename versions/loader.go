@@ -0,0 +1,160 @@
+package versions
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// Loader returns the source of a version of the typescript compiler. Registries call the loader the first
+// time they compile the version instead of holding on to the source, so that sources that are expensive to
+// produce, such as the compressed sources embedded in the version packages, are only produced when used.
+type Loader func() (string, error)
+
+// LoaderRegistry is implemented by registries that can register a Loader in place of the source.
+type LoaderRegistry interface {
+	// RegisterLoader registers the loader to the specified tag, replacing any source registered to the tag.
+	RegisterLoader(tag string, loader Loader)
+}
+
+// RegisterLoader registers the loader to the specified tag in the registry. Registries that aren't a
+// LoaderRegistry are passed the source immediately, which is the only case in which an error is returned.
+func RegisterLoader(r Registry, tag string, loader Loader) error {
+	if lr, ok := r.(LoaderRegistry); ok {
+		lr.RegisterLoader(tag, loader)
+		return nil
+	}
+	source, err := loader()
+	if err != nil {
+		return fmt.Errorf("loading source for tag '%s': %w", tag, err)
+	}
+	r.Register(tag, source)
+	return nil
+}
+
+// GzipLoader returns a Loader that decompresses the gzipped source each time it is called.
+func GzipLoader(compressed []byte) Loader {
+	return func() (string, error) {
+		r, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return "", fmt.Errorf("decompressing source: %w", err)
+		}
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("decompressing source: %w", err)
+		}
+		return string(b), nil
+	}
+}
+
+// GzipFileLoader returns a Loader that decompresses the gzipped source in the named file of fsys each time it
+// is called. Unlike GzipLoader, the compressed source isn't copied out of an embed.FS.
+func GzipFileLoader(fsys fs.FS, name string) Loader {
+	return func() (string, error) {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return "", fmt.Errorf("opening source: %w", err)
+		}
+		defer f.Close()
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			return "", fmt.Errorf("decompressing source: %w", err)
+		}
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("decompressing source: %w", err)
+		}
+		return string(b), nil
+	}
+}
+
+// RegisterLibs registers the lib files to the specified tag if the registry is a LibRegistry and libs isn't nil.
+func RegisterLibs(r Registry, tag string, libs fs.FS) {
+	if lr, ok := r.(LibRegistry); ok && libs != nil {
+		lr.RegisterLibs(tag, libs)
+	}
+}
+
+// EmbeddedLibs returns a file system of the gzipped lib.*.d.ts.gz files at the root of fsys, with the files
+// decompressed and their ".gz" extension removed as by GzipFS, or nil if there are no lib files. Other files
+// in fsys, such as the compressed compiler, aren't part of the returned file system.
+func EmbeddedLibs(fsys fs.FS) fs.FS {
+	libs := libFS{fsys: fsys, suffix: ".d.ts.gz"}
+	entries, err := fs.ReadDir(libs, ".")
+	if err != nil || len(entries) == 0 {
+		return nil
+	}
+	return GzipFS(libs)
+}
+
+// libFS limits a file system to the lib files at its root, which are the files named lib.* with the suffix,
+// such as lib.*.d.ts.gz.
+type libFS struct {
+	fsys   fs.FS
+	suffix string
+}
+
+func (l libFS) Open(name string) (fs.File, error) {
+	if name != "." && !isLibFile(name, l.suffix) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return l.fsys.Open(name)
+}
+
+func (l libFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(l.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	out := entries[:0]
+	for _, e := range entries {
+		if !e.IsDir() && isLibFile(e.Name(), l.suffix) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// isLibFile returns true for the names of lib files with the suffix, such as lib.d.ts.gz and lib.es5.d.ts.gz
+// for the suffix ".d.ts.gz".
+func isLibFile(name, suffix string) bool {
+	return strings.HasPrefix(name, "lib.") && strings.HasSuffix(name, suffix) && !strings.Contains(name, "/")
+}
+
+// pendingProgram is a version that a registry is loading and compiling without holding its lock. Callers that
+// get the same version in the meantime wait for done rather than compiling it again.
+type pendingProgram struct {
+	done chan struct{}
+	prg  *goja.Program
+	err  error
+}
+
+// compileLoader loads and compiles the source of the version registered to the tag.
+func compileLoader(tag string, loader Loader) (*goja.Program, error) {
+	source, err := loader()
+	if err != nil {
+		return nil, fmt.Errorf("loading registered source for tag '%s': %w", tag, err)
+	}
+	prg, err := goja.Compile("", source, true)
+	if err != nil {
+		return nil, fmt.Errorf("compiling registered source for tag '%s': %w", tag, err)
+	}
+	return prg, nil
+}
+
+// sourceLoader returns a Loader for a source that is already in memory.
+func sourceLoader(source string) Loader {
+	return func() (string, error) {
+		return source, nil
+	}
+}
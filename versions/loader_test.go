@@ -0,0 +1,99 @@
+package versions
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/stretchr/testify/require"
+)
+
+// sourceRegistry is a Registry that isn't a LoaderRegistry.
+type sourceRegistry map[string]string
+
+func (r sourceRegistry) Register(tag string, source string) { r[tag] = source }
+func (r sourceRegistry) Get(string) (*goja.Program, error)  { return nil, errors.New("not implemented") }
+
+func TestGzipLoader(t *testing.T) {
+	source, err := GzipLoader(gzipBytes(t, "var a = 10;"))()
+	require.NoError(t, err)
+	require.Equal(t, "var a = 10;", source)
+
+	_, err = GzipLoader([]byte("not gzipped"))()
+	require.Error(t, err)
+}
+
+func TestRegisterLoader(t *testing.T) {
+	for name, r := range map[string]interface {
+		Registry
+		LoaderRegistry
+	}{"caching": NewRegistry(), "expiring": NewExpiringRegistry(time.Minute)} {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			require.NoError(t, RegisterLoader(r, "v4.2.3", func() (string, error) {
+				calls++
+				return "var a = 10;", nil
+			}))
+			require.Equal(t, 0, calls, "sources are loaded when they are first compiled")
+			_, err := r.Get("v4.2.3")
+			require.NoError(t, err)
+			_, err = r.Get("v4.2.3")
+			require.NoError(t, err)
+			require.Equal(t, 1, calls)
+
+			r.RegisterLoader("v4.2.4", GzipLoader([]byte("not gzipped")))
+			_, err = r.Get("v4.2.4")
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "loading registered source for tag 'v4.2.4'")
+		})
+	}
+
+	t.Run("Registry", func(t *testing.T) {
+		r := sourceRegistry{}
+		require.NoError(t, RegisterLoader(r, "v4.2.3", GzipLoader(gzipBytes(t, "var a = 10;"))))
+		require.Equal(t, "var a = 10;", r["v4.2.3"])
+		require.Error(t, RegisterLoader(r, "v4.2.4", GzipLoader([]byte("not gzipped"))))
+	})
+}
+
+func TestGzipFileLoader(t *testing.T) {
+	fsys := fstest.MapFS{"v4.2.3.js.gz": {Data: gzipBytes(t, "var a = 10;")}}
+	source, err := GzipFileLoader(fsys, "v4.2.3.js.gz")()
+	require.NoError(t, err)
+	require.Equal(t, "var a = 10;", source)
+
+	_, err = GzipFileLoader(fsys, "v4.2.4.js.gz")()
+	require.Error(t, err)
+}
+
+func TestEmbeddedLibs(t *testing.T) {
+	require.Nil(t, EmbeddedLibs(fstest.MapFS{"v4.2.3.js.gz": {Data: gzipBytes(t, "var a = 10;")}}))
+
+	libs := EmbeddedLibs(fstest.MapFS{
+		"v4.2.3.js.gz":       {Data: gzipBytes(t, "var a = 10;")},
+		"lib.d.ts.gz":        {Data: gzipBytes(t, "/// <reference lib=\"es5\" />")},
+		"lib.es5.d.ts.gz":    {Data: gzipBytes(t, "interface Array<T> {}")},
+		"nested/lib.d.ts.gz": {Data: gzipBytes(t, "")},
+	})
+	require.NotNil(t, libs)
+	b, err := fs.ReadFile(libs, "lib.es5.d.ts")
+	require.NoError(t, err)
+	require.Equal(t, "interface Array<T> {}", string(b))
+	_, err = fs.ReadFile(libs, "v4.2.3.js")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	entries, err := fs.ReadDir(libs, ".")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	r := NewRegistry()
+	RegisterLibs(r, "v4.2.3", nil)
+	_, err = r.Libs("v4.2.3")
+	require.Error(t, err)
+	RegisterLibs(r, "v4.2.3", libs)
+	registered, err := r.Libs("v4.2.3")
+	require.NoError(t, err)
+	require.Equal(t, libs, registered)
+}
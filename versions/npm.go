@@ -0,0 +1,210 @@
+package versions
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing/fstest"
+
+	"github.com/dop251/goja"
+)
+
+// NpmPackage is a version of the typescript compiler read from the typescript npm package.
+type NpmPackage struct {
+	// Tag is the version in the package.json prefixed with "v", such as "v4.9.3".
+	Tag string
+	// Source is the contents of lib/typescript.js.
+	Source string
+	// Libs contains the lib.*.d.ts files from the lib directory at its root.
+	Libs fs.FS
+
+	verifyOnce sync.Once
+	verifyErr  error
+}
+
+// ReadNpmPackage reads the typescript compiler from an unpacked typescript npm package, where the root of
+// fsys is the package directory, such as os.DirFS("node_modules/typescript"). The version is read from the
+// package.json, it is verified against the ts.version of the compiler when the compiler is first loaded from
+// a registry it is registered in, see Loader.
+func ReadNpmPackage(fsys fs.FS) (*NpmPackage, error) {
+	b, err := fs.ReadFile(fsys, "package.json")
+	if err != nil {
+		return nil, fmt.Errorf("reading package.json: %w", err)
+	}
+	tag, err := packageTag(b)
+	if err != nil {
+		return nil, err
+	}
+	b, err = fs.ReadFile(fsys, "lib/typescript.js")
+	if err != nil {
+		return nil, fmt.Errorf("reading lib/typescript.js: %w", err)
+	}
+	libs, err := fs.Sub(fsys, "lib")
+	if err != nil {
+		return nil, fmt.Errorf("reading lib: %w", err)
+	}
+	return &NpmPackage{Tag: tag, Source: string(b), Libs: libFS{fsys: libs, suffix: ".d.ts"}}, nil
+}
+
+// ReadNpmArchive reads the typescript compiler from a typescript npm package archive, such as the
+// typescript-4.9.3.tgz created by npm pack. Only the package.json, lib/typescript.js and lib/lib.*.d.ts
+// files are kept in memory. See ReadNpmPackage.
+func ReadNpmArchive(r io.Reader) (*NpmPackage, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing archive: %w", err)
+	}
+	defer gr.Close()
+	files := fstest.MapFS{}
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		// npm archives contain a single top level directory, which is usually named package
+		name := header.Name
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			name = name[i+1:]
+		}
+		if !isPackageFile(name) {
+			continue
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from archive: %w", name, err)
+		}
+		files[name] = &fstest.MapFile{Data: b, Mode: 0444}
+	}
+	p, err := ReadNpmPackage(files)
+	if err != nil {
+		return nil, err
+	}
+	// The compiler is only needed as the Source, don't hold on to a second copy
+	delete(files, "lib/typescript.js")
+	return p, nil
+}
+
+// ReadNpmArchiveFile reads the typescript compiler from the npm package archive file, see ReadNpmArchive.
+func ReadNpmArchiveFile(file string) (*NpmPackage, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("opening archive: %w", err)
+	}
+	defer f.Close()
+	return ReadNpmArchive(f)
+}
+
+// Loader returns a Loader for the compiler that verifies the version of the compiler the first time it is
+// called, so that a compiler that is never used isn't run. The verification runs the compiler and checks that
+// its ts.version matches the version in the package.json.
+func (p *NpmPackage) Loader() Loader {
+	return func() (string, error) {
+		p.verifyOnce.Do(func() {
+			p.verifyErr = p.verify()
+		})
+		if p.verifyErr != nil {
+			return "", p.verifyErr
+		}
+		return p.Source, nil
+	}
+}
+
+// Register registers the compiler's Loader to the package's tag in the registry, see RegisterLoader, and its
+// lib files if the registry is a LibRegistry.
+func (p *NpmPackage) Register(r Registry) error {
+	err := RegisterLoader(r, p.Tag, p.Loader())
+	if err != nil {
+		return err
+	}
+	RegisterLibs(r, p.Tag, p.Libs)
+	return nil
+}
+
+// RegisterNpmPackage reads the unpacked typescript npm package in fsys, see ReadNpmPackage, and registers it
+// in the registry. It returns the tag the compiler was registered to.
+func RegisterNpmPackage(r Registry, fsys fs.FS) (string, error) {
+	p, err := ReadNpmPackage(fsys)
+	if err != nil {
+		return "", err
+	}
+	err = p.Register(r)
+	if err != nil {
+		return "", err
+	}
+	return p.Tag, nil
+}
+
+// RegisterNpmArchive reads the typescript npm package archive file, see ReadNpmArchive, and registers it in the
+// registry. It returns the tag the compiler was registered to.
+func RegisterNpmArchive(r Registry, file string) (string, error) {
+	p, err := ReadNpmArchiveFile(file)
+	if err != nil {
+		return "", err
+	}
+	err = p.Register(r)
+	if err != nil {
+		return "", err
+	}
+	return p.Tag, nil
+}
+
+// verify runs the compiler and checks that its ts.version matches the package's version.
+func (p *NpmPackage) verify() error {
+	runtime := goja.New()
+	_, err := runtime.RunScript("typescript.js", p.Source)
+	if err != nil {
+		return fmt.Errorf("running lib/typescript.js: %w", err)
+	}
+	ts := runtime.Get("ts")
+	if ts == nil || goja.IsUndefined(ts) || goja.IsNull(ts) {
+		return fmt.Errorf("lib/typescript.js doesn't define ts")
+	}
+	version := ts.ToObject(runtime).Get("version")
+	if version == nil || "v"+version.String() != p.Tag {
+		return fmt.Errorf("package.json version '%s' doesn't match ts.version '%v'", strings.TrimPrefix(p.Tag, "v"), version)
+	}
+	return nil
+}
+
+// packageTag returns the tag for the version in the package.json of the typescript package.
+func packageTag(b []byte) (string, error) {
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	err := json.Unmarshal(b, &pkg)
+	if err != nil {
+		return "", fmt.Errorf("parsing package.json: %w", err)
+	}
+	if pkg.Name != "typescript" {
+		return "", fmt.Errorf("package.json is for package '%s', not typescript", pkg.Name)
+	}
+	if pkg.Version == "" {
+		return "", fmt.Errorf("package.json doesn't have a version")
+	}
+	return "v" + pkg.Version, nil
+}
+
+// isPackageFile returns true for the files in the typescript package that ReadNpmPackage uses.
+func isPackageFile(name string) bool {
+	if name == "package.json" || name == "lib/typescript.js" {
+		return true
+	}
+	dir, file := path.Split(name)
+	return dir == "lib/" && isLibFile(file, ".d.ts")
+}
@@ -0,0 +1,136 @@
+package versions
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func npmPackage(version, tsVersion string) fstest.MapFS {
+	return fstest.MapFS{
+		"package.json":           {Data: []byte(`{"name": "typescript", "version": "` + version + `"}`)},
+		"lib/typescript.js":      {Data: []byte(`var ts = { version: "` + tsVersion + `" };`)},
+		"lib/lib.d.ts":           {Data: []byte("interface Array<T> {}")},
+		"lib/lib.es5.d.ts":       {Data: []byte("interface Object {}")},
+		"lib/tsserver.js":        {Data: []byte("var server;")},
+		"bin/tsc":                {Data: []byte("#!/usr/bin/env node")},
+		"lib/zh-cn/lib.d.ts":     {Data: []byte("")},
+		"lib/typescriptServices": {Data: []byte("")},
+	}
+}
+
+// npmArchive returns the files packed like npm pack, in a directory named package.
+func npmArchive(t *testing.T, files fstest.MapFS) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "package/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for name, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "package/" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(f.Data))}))
+		_, err := tw.Write(f.Data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestReadNpmPackage(t *testing.T) {
+	p, err := ReadNpmPackage(npmPackage("4.9.3", "4.9.3"))
+	require.NoError(t, err)
+	require.Equal(t, "v4.9.3", p.Tag)
+	require.Equal(t, `var ts = { version: "4.9.3" };`, p.Source)
+	b, err := fs.ReadFile(p.Libs, "lib.es5.d.ts")
+	require.NoError(t, err)
+	require.Equal(t, "interface Object {}", string(b))
+	_, err = fs.Stat(p.Libs, "typescript.js")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	source, err := p.Loader()()
+	require.NoError(t, err)
+	require.Equal(t, p.Source, source)
+
+	t.Run("VersionMismatch", func(t *testing.T) {
+		p, err := ReadNpmPackage(npmPackage("4.9.3", "4.9.4"))
+		require.NoError(t, err)
+		_, err = p.Loader()()
+		require.EqualError(t, err, "package.json version '4.9.3' doesn't match ts.version '4.9.4'")
+	})
+	t.Run("NotTypescript", func(t *testing.T) {
+		files := npmPackage("4.9.3", "4.9.3")
+		files["package.json"] = &fstest.MapFile{Data: []byte(`{"name": "left-pad", "version": "1.3.0"}`)}
+		_, err := ReadNpmPackage(files)
+		require.EqualError(t, err, "package.json is for package 'left-pad', not typescript")
+	})
+	t.Run("MissingCompiler", func(t *testing.T) {
+		files := npmPackage("4.9.3", "4.9.3")
+		delete(files, "lib/typescript.js")
+		_, err := ReadNpmPackage(files)
+		require.Error(t, err)
+	})
+	t.Run("InvalidCompiler", func(t *testing.T) {
+		files := npmPackage("4.9.3", "4.9.3")
+		files["lib/typescript.js"] = &fstest.MapFile{Data: []byte("var a = 10;")}
+		p, err := ReadNpmPackage(files)
+		require.NoError(t, err)
+		_, err = p.Loader()()
+		require.EqualError(t, err, "lib/typescript.js doesn't define ts")
+	})
+}
+
+func TestReadNpmArchive(t *testing.T) {
+	p, err := ReadNpmArchive(bytes.NewReader(npmArchive(t, npmPackage("5.0.0-beta", "5.0.0-beta"))))
+	require.NoError(t, err)
+	require.Equal(t, "v5.0.0-beta", p.Tag)
+
+	// Only the compiler and the lib files are kept
+	var names []string
+	require.NoError(t, fs.WalkDir(p.Libs, ".", func(path string, d fs.DirEntry, err error) error {
+		if !d.IsDir() {
+			names = append(names, path)
+		}
+		return err
+	}))
+	require.Equal(t, []string{"lib.d.ts", "lib.es5.d.ts"}, names)
+	require.Equal(t, `var ts = { version: "5.0.0-beta" };`, p.Source)
+
+	_, err = ReadNpmArchive(bytes.NewReader([]byte("not an archive")))
+	require.Error(t, err)
+}
+
+func TestRegisterNpm(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "typescript-4.8.4.tgz")
+	require.NoError(t, os.WriteFile(file, npmArchive(t, npmPackage("4.8.4", "4.8.4")), 0644))
+
+	r := NewRegistry()
+	tag, err := RegisterNpmArchive(r, file)
+	require.NoError(t, err)
+	require.Equal(t, "v4.8.4", tag)
+	tag, err = RegisterNpmPackage(r, npmPackage("4.9.3", "4.9.3"))
+	require.NoError(t, err)
+	require.Equal(t, "v4.9.3", tag)
+
+	for _, tag := range []string{"v4.8.4", "v4.9.3"} {
+		_, err = r.Get(tag)
+		require.NoError(t, err)
+		libs, err := r.Libs(tag)
+		require.NoError(t, err)
+		_, err = fs.Stat(libs, "lib.d.ts")
+		require.NoError(t, err)
+	}
+
+	_, err = RegisterNpmArchive(r, filepath.Join(t.TempDir(), "missing.tgz"))
+	require.Error(t, err)
+
+	// The version is verified when the compiler is first loaded
+	tag, err = RegisterNpmPackage(r, npmPackage("4.9.4", "4.9.3"))
+	require.NoError(t, err)
+	_, err = r.Get(tag)
+	require.EqualError(t, err, "loading registered source for tag 'v4.9.4': package.json version '4.9.4' doesn't match ts.version '4.9.3'")
+}
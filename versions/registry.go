@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/dop251/goja"
 	"github.com/stretchr/testify/assert"
+	"io/fs"
 	"sync"
 	"testing"
 )
@@ -13,66 +14,157 @@ type Registry interface {
 	Get(tag string) (*goja.Program, error)
 }
 
+// LibRegistry is implemented by registries that can also store the default library declaration files
+// (lib.d.ts, lib.es2015.d.ts, etc.) that ship with each version of the typescript compiler. These files
+// are needed by the compiler to type-check programs that use the built-in types such as Array and Promise.
+type LibRegistry interface {
+	// RegisterLibs registers the file system containing the lib.*.d.ts files for the specified tag. The
+	// files must be at the root of the file system. Use GzipFS for compressed lib files.
+	RegisterLibs(tag string, libs fs.FS)
+	// Libs returns the file system containing the lib.*.d.ts files registered for the specified tag.
+	Libs(tag string) (fs.FS, error)
+}
+
+// Resolver is implemented by registries that can resolve version constraints, such as "^4.2" or "latest", to
+// the tag of a registered version. See Resolve for the supported constraints.
+type Resolver interface {
+	// Resolve returns the tag of the highest registered version that satisfies the constraint.
+	Resolve(constraint string) (string, error)
+}
+
 // CachingRegistry is a thread-safe registry for storing tagged versions of the typescript source code.
 type CachingRegistry struct {
 	lock     sync.Mutex
-	versions map[string]string
+	versions map[string]Loader
 	compiled map[string]*goja.Program
+	pending  map[string]*pendingProgram
+	libs     map[string]fs.FS
 }
 
 // Register registers the provided source to the specified tag in the registry.
 func (r *CachingRegistry) Register(tag string, source string) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
-	r.versions[tag] = source
+	r.versions[tag] = sourceLoader(source)
 	delete(r.compiled, tag)
+	delete(r.pending, tag)
 }
 
-// Get attempts to return the typescript source for the specified tag if it exists, otherwise
-// it returns an error with a list of typescript versions that are supported by this registry.
-func (r *CachingRegistry) Get(tag string) (*goja.Program, error) {
+// RegisterLoader registers the loader to the specified tag in the registry. The loader is called the first
+// time the version is compiled.
+func (r *CachingRegistry) RegisterLoader(tag string, loader Loader) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
+	r.versions[tag] = loader
+	delete(r.compiled, tag)
+	delete(r.pending, tag)
+}
 
-	prg, ok := r.compiled[tag]
-	if ok {
-		return prg, nil
-	}
-	src, ok := r.versions[tag]
+// RegisterLibs registers the file system containing the lib.*.d.ts files for the specified tag.
+func (r *CachingRegistry) RegisterLibs(tag string, libs fs.FS) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.libs[tag] = libs
+}
+
+// Libs returns the file system containing the lib.*.d.ts files for the specified tag, or an error if
+// no lib files have been registered for the tag.
+func (r *CachingRegistry) Libs(tag string) (fs.FS, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	libs, ok := r.libs[tag]
 	if !ok {
-		return nil, fmt.Errorf("unsupported version tag '%s', must be one of %v", tag, r.supportedVersionsLocked())
+		return nil, fmt.Errorf("no lib files registered for version tag '%s'", tag)
 	}
-	prg, err := goja.Compile("", src, true)
+	return libs, nil
+}
+
+// Get attempts to return the typescript source for the specified tag if it exists, otherwise
+// it returns an error with a list of typescript versions that are supported by this registry. The tag
+// may also be a version constraint, see Resolve. The source is loaded and compiled without holding the
+// registry's lock, so that a slow Loader doesn't block getting other versions.
+func (r *CachingRegistry) Get(tag string) (*goja.Program, error) {
+	r.lock.Lock()
+	tag, err := r.resolveLocked(tag)
 	if err != nil {
-		return nil, fmt.Errorf("compiling registered source for tag '%s': %w", tag, err)
+		r.lock.Unlock()
+		return nil, err
+	}
+	if prg, ok := r.compiled[tag]; ok {
+		r.lock.Unlock()
+		return prg, nil
+	}
+	p, ok := r.pending[tag]
+	if !ok {
+		p = &pendingProgram{done: make(chan struct{})}
+		r.pending[tag] = p
+		loader := r.versions[tag]
+		r.lock.Unlock()
+		p.prg, p.err = compileLoader(tag, loader)
+		r.lock.Lock()
+		// The program is discarded if the version was registered again while it was loading
+		if r.pending[tag] == p {
+			delete(r.pending, tag)
+			if p.err == nil {
+				r.compiled[tag] = p.prg
+			}
+		}
+		close(p.done)
 	}
-	r.compiled[tag] = prg
-	return prg, nil
+	r.lock.Unlock()
+	<-p.done
+	return p.prg, p.err
+}
+
+// Resolve returns the tag of the highest registered version that satisfies the constraint, see Resolve.
+func (r *CachingRegistry) Resolve(constraint string) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.resolveLocked(constraint)
+}
+
+// RegisteredVersions returns the versions that are registered in this registry, in ascending order.
+func (r *CachingRegistry) RegisteredVersions() []Version {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.registeredVersionsLocked()
 }
 
-// RegisteredVersions returns an unordered list of the versions that are registered in this registry
-func (r *CachingRegistry) RegisteredVersions() (out []string) {
-	for k, _ := range r.versions {
-		out = append(out, k)
+// resolveLocked resolves the constraint against the registered versions. This function should only be
+// called by a caller who has already acquired a lock on the registry.
+func (r *CachingRegistry) resolveLocked(constraint string) (string, error) {
+	if _, ok := r.versions[constraint]; ok {
+		return constraint, nil
 	}
-	return
+	v, err := Resolve(constraint, r.registeredVersionsLocked())
+	if err != nil {
+		return "", err
+	}
+	return v.Tag, nil
 }
 
-// supportedVersionsLocked returns a slice of supported version tags that are registered
-// to this registry and can be accessed by calling Get. This function should only be called
-// by a caller who has already acquired a lock on the registry.
-func (r *CachingRegistry) supportedVersionsLocked() (out []string) {
-	for k, _ := range r.versions {
-		out = append(out, k)
+// registeredVersionsLocked returns the versions that are registered to this registry, in ascending
+// order. This function should only be called by a caller who has already acquired a lock on the registry.
+func (r *CachingRegistry) registeredVersionsLocked() []Version {
+	tags := make([]string, 0, len(r.versions))
+	for k := range r.versions {
+		tags = append(tags, k)
 	}
-	return
+	return sortVersions(tags)
 }
 
+// DefaultRegistry is the registry used by the default config. Each of the bundled version packages, such as
+// versions/v4.9.3, registers its compiler in this registry when it is imported, and versions/all imports
+// all of them.
+var DefaultRegistry = NewRegistry()
+
 // NewRegistry creates a new instances of a version registry
 func NewRegistry() *CachingRegistry {
 	return &CachingRegistry{
-		versions: make(map[string]string),
+		versions: make(map[string]Loader),
 		compiled: make(map[string]*goja.Program),
+		pending:  make(map[string]*pendingProgram),
+		libs:     make(map[string]fs.FS),
 	}
 }
 
@@ -84,3 +176,21 @@ func TestSource(t *testing.T, version, source string) {
 	_, err := r.Get(version)
 	assert.NoErrorf(t, err, "failed to register %v", version)
 }
+
+// TestLibs is a helper function for testing that the lib files of a version of the Typescript compiler
+// contain the default lib files.
+func TestLibs(t *testing.T, libs fs.FS) {
+	for _, name := range []string{"lib.d.ts", "lib.es5.d.ts", "lib.es2015.promise.d.ts"} {
+		_, err := fs.Stat(libs, name)
+		assert.NoErrorf(t, err, "missing lib file %v", name)
+	}
+}
+
+// TestLoader is a helper function for testing that versions of the Typescript compiler can
+// properly be registered with a loader.
+func TestLoader(t *testing.T, version string, loader Loader) {
+	r := NewRegistry()
+	r.RegisterLoader(version, loader)
+	_, err := r.Get(version)
+	assert.NoErrorf(t, err, "failed to register %v", version)
+}
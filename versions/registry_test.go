@@ -1,8 +1,13 @@
 package versions
 
 import (
-	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestRegistry_Get(t *testing.T) {
@@ -18,6 +23,41 @@ func TestRegistry_Get(t *testing.T) {
 	})
 }
 
+func TestRegistry_SlowLoader(t *testing.T) {
+	for name, r := range map[string]interface {
+		Registry
+		LoaderRegistry
+	}{"caching": NewRegistry(), "expiring": NewExpiringRegistry(time.Minute)} {
+		t.Run(name, func(t *testing.T) {
+			release := make(chan struct{})
+			var calls int32
+			r.RegisterLoader("v1.0.0", func() (string, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "var a = 10;", nil
+			})
+			r.Register("v2.0.0", "var b = 10;")
+
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := r.Get("v1.0.0")
+					require.NoError(t, err)
+				}()
+			}
+			require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+			// Other versions can be compiled while the slow version is loading
+			_, err := r.Get("v2.0.0")
+			require.NoError(t, err)
+			close(release)
+			wg.Wait()
+			require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		})
+	}
+}
+
 func TestRegistry_Register(t *testing.T) {
 	r := NewRegistry()
 	t.Run("ValidJavascript", func(t *testing.T) {
@@ -35,3 +75,15 @@ func TestRegistry_Register(t *testing.T) {
 		require.Len(t, r.RegisteredVersions(), 1)
 	})
 }
+
+func TestRegistry_Libs(t *testing.T) {
+	r := NewRegistry()
+	_, err := r.Libs("v4.2.3")
+	require.Error(t, err)
+
+	libs := fstest.MapFS{"lib.d.ts": {Data: []byte("interface Array<T> {}")}}
+	r.RegisterLibs("v4.2.3", libs)
+	fsys, err := r.Libs("v4.2.3")
+	require.NoError(t, err)
+	require.Equal(t, libs, fsys)
+}
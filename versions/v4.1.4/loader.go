@@ -1,8 +1,42 @@
+// Package v4_1_4 embeds the gzipped source of version 4.1.4 of the typescript compiler and its vendored
+// lib.*.d.ts files. Importing the package registers the compiler in versions.DefaultRegistry, and only
+// programs that import it link the source, which is decompressed the first time a registry compiles it.
 package v4_1_4
 
+//go:generate go run ../internal/vendorlibs -tag v4.1.4
+
 import (
-	_ "embed"
+	"embed"
+
+	"github.com/clarkmcc/go-typescript/versions"
 )
 
-//go:embed v4.1.4.js
-var Source string
+// Tag is the tag the compiler is registered to by Register.
+const Tag = "v4.1.4"
+
+// files contains the compressed compiler and lib files, the lib files are written by go generate.
+//
+//go:embed *.gz
+var files embed.FS
+
+// Source decompresses and returns the source of the compiler.
+var Source versions.Loader = versions.GzipFileLoader(files, Tag+".js.gz")
+
+// Libs contains the lib.*.d.ts files of the compiler, or is nil if they haven't been vendored.
+var Libs = versions.EmbeddedLibs(files)
+
+// Register registers the compiler and its lib files to Tag in the registry, see versions.RegisterLoader and
+// versions.RegisterLibs.
+func Register(r versions.Registry) error {
+	err := versions.RegisterLoader(r, Tag, Source)
+	if err != nil {
+		return err
+	}
+	versions.RegisterLibs(r, Tag, Libs)
+	return nil
+}
+
+func init() {
+	versions.DefaultRegistry.RegisterLoader(Tag, Source)
+	versions.RegisterLibs(versions.DefaultRegistry, Tag, Libs)
+}
@@ -6,5 +6,12 @@ import (
 )
 
 func TestRegister(t *testing.T) {
-	versions.TestSource(t, "v4.1.4", Source)
+	versions.TestLoader(t, Tag, Source)
+}
+
+func TestLibs(t *testing.T) {
+	if Libs == nil {
+		t.Skip("the lib files aren't vendored, run go generate")
+	}
+	versions.TestLibs(t, Libs)
 }
@@ -0,0 +1,355 @@
+package versions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a registered typescript version. Tags that are semantic versions, such as "v4.9.3" or
+// "5.0.0-beta", have their components parsed. Other tags are still valid registry tags, but can only be
+// selected by their exact tag.
+type Version struct {
+	Tag        string
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+
+	semver bool
+}
+
+// ParseVersion parses a tag in the form [v]major.minor.patch[-prerelease].
+func ParseVersion(tag string) (Version, error) {
+	s := strings.TrimPrefix(tag, "v")
+	var prerelease string
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, prerelease = s[:i], s[i+1:]
+		if prerelease == "" {
+			return Version{}, fmt.Errorf("invalid version '%s': empty prerelease", tag)
+		}
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version '%s': must be major.minor.patch", tag)
+	}
+	var numbers [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version '%s': '%s' is not a number", tag, p)
+		}
+		numbers[i] = n
+	}
+	return Version{Tag: tag, Major: numbers[0], Minor: numbers[1], Patch: numbers[2], Prerelease: prerelease, semver: true}, nil
+}
+
+// IsSemver returns true if the version's tag is a semantic version.
+func (v Version) IsSemver() bool {
+	return v.semver
+}
+
+// String returns the version's tag.
+func (v Version) String() string {
+	return v.Tag
+}
+
+// Compare returns -1, 0 or 1 if v is lower than, equal to, or higher than other. Prereleases are lower than
+// the release of the same version, and tags that aren't semantic versions are lower than all semantic
+// versions and are ordered by tag.
+func (v Version) Compare(other Version) int {
+	if v.semver != other.semver {
+		if v.semver {
+			return 1
+		}
+		return -1
+	}
+	if !v.semver {
+		return strings.Compare(v.Tag, other.Tag)
+	}
+	for _, d := range [3]int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+	return strings.Compare(v.Prerelease, other.Prerelease)
+}
+
+// sortVersions parses the tags and returns the versions in ascending order.
+func sortVersions(tags []string) []Version {
+	out := make([]Version, 0, len(tags))
+	for _, tag := range tags {
+		v, err := ParseVersion(tag)
+		if err != nil {
+			v = Version{Tag: tag}
+		}
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Compare(out[j]) < 0
+	})
+	return out
+}
+
+// comparator is a single comparison, such as >=4.2.0, that a version must satisfy.
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	n := v.Compare(c.version)
+	switch c.op {
+	case ">=":
+		return n >= 0
+	case ">":
+		return n > 0
+	case "<=":
+		return n <= 0
+	case "<":
+		return n < 0
+	}
+	return n == 0
+}
+
+// parseConstraint parses a version constraint into the comparators that a matching version must all satisfy.
+// Constraints are npm style ranges: exact versions such as 4.9.3, caret ranges such as ^4.2, tilde ranges such
+// as ~4.1.4, wildcards such as 4.x or 4.1.*, partial versions such as 4.1, comparisons such as >=4.1 and any
+// space separated combination of these. The v prefix is optional everywhere.
+func parseConstraint(constraint string) ([]comparator, error) {
+	var out []comparator
+	fields := strings.Fields(constraint)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+	for _, field := range fields {
+		comparators, err := parseRange(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint '%s': %w", constraint, err)
+		}
+		out = append(out, comparators...)
+	}
+	return out, nil
+}
+
+// parseRange parses a single range, such as ^4.2, into comparators.
+func parseRange(s string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, s[len(prefix):]
+			break
+		}
+	}
+	s = strings.TrimPrefix(s, "v")
+	var prerelease string
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, prerelease = s[:i], s[i+1:]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return nil, fmt.Errorf("'%s' has too many components", s)
+	}
+	// The number of components that are specified, the rest are wildcards
+	var numbers [3]int
+	specified := 0
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("'%s' is not a number", p)
+		}
+		if specified != i {
+			return nil, fmt.Errorf("'%s' follows a wildcard", p)
+		}
+		numbers[i] = n
+		specified++
+	}
+	if prerelease != "" && specified < 3 {
+		return nil, fmt.Errorf("a prerelease requires a full version")
+	}
+	lower := Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2], Prerelease: prerelease, semver: true}
+	if specified == 0 {
+		if op == "<" || op == ">" {
+			// Nothing is lower or higher than every version
+			return []comparator{{op: "<", version: Version{semver: true}}}, nil
+		}
+		return []comparator{{op: ">=", version: Version{semver: true}}}, nil
+	}
+
+	// upper returns the lowest version above the range where the first n components are fixed
+	upper := func(n int) Version {
+		v := Version{Major: numbers[0], semver: true}
+		switch n {
+		case 1:
+			v.Major++
+		case 2:
+			v.Minor = numbers[1] + 1
+		case 3:
+			v.Minor, v.Patch = numbers[1], numbers[2]+1
+		}
+		return v
+	}
+	switch op {
+	case "^":
+		// The leftmost non-zero component is fixed
+		fixed := specified
+		switch {
+		case numbers[0] > 0 || specified == 1:
+			fixed = 1
+		case numbers[1] > 0 || specified == 2:
+			fixed = 2
+		}
+		return []comparator{{op: ">=", version: lower}, {op: "<", version: upper(fixed)}}, nil
+	case "~":
+		fixed := 2
+		if specified == 1 {
+			fixed = 1
+		}
+		return []comparator{{op: ">=", version: lower}, {op: "<", version: upper(fixed)}}, nil
+	case "", "=":
+		if specified == 3 {
+			return []comparator{{op: "=", version: lower}}, nil
+		}
+		return []comparator{{op: ">=", version: lower}, {op: "<", version: upper(specified)}}, nil
+	case ">":
+		if specified < 3 {
+			return []comparator{{op: ">=", version: upper(specified)}}, nil
+		}
+	case "<=":
+		if specified < 3 {
+			return []comparator{{op: "<", version: upper(specified)}}, nil
+		}
+	}
+	return []comparator{{op: op, version: lower}}, nil
+}
+
+// Resolve returns the highest of the versions that satisfies the constraint. A constraint that is the tag of
+// one of the versions always resolves to that version. "latest" resolves to the highest version that isn't a
+// prerelease, or the highest prerelease if there are only prereleases. Prereleases only satisfy constraints
+// that name them exactly. The error for a constraint that isn't satisfied suggests the closest versions.
+func Resolve(constraint string, versions []Version) (Version, error) {
+	for _, v := range versions {
+		if v.Tag == constraint {
+			return v, nil
+		}
+	}
+	var best *Version
+	if constraint == "latest" {
+		for _, prereleases := range []bool{false, true} {
+			for i, v := range versions {
+				if v.semver && (prereleases || v.Prerelease == "") && (best == nil || v.Compare(*best) > 0) {
+					best = &versions[i]
+				}
+			}
+			if best != nil {
+				break
+			}
+		}
+	} else if comparators, err := parseConstraint(constraint); err == nil {
+		for i, v := range versions {
+			if !v.semver || best != nil && v.Compare(*best) <= 0 {
+				continue
+			}
+			if v.Prerelease != "" && !namesPrerelease(comparators, v) {
+				continue
+			}
+			matches := true
+			for _, c := range comparators {
+				matches = matches && c.matches(v)
+			}
+			if matches {
+				best = &versions[i]
+			}
+		}
+	}
+	if best == nil {
+		return Version{}, unsupportedVersionError(constraint, versions)
+	}
+	return *best, nil
+}
+
+// namesPrerelease returns true if one of the comparators is for the prerelease's version.
+func namesPrerelease(comparators []comparator, v Version) bool {
+	for _, c := range comparators {
+		if c.version.Prerelease != "" && c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedVersionError returns the error for a constraint that none of the versions satisfy, suggesting
+// the versions closest to the version in the constraint.
+func unsupportedVersionError(constraint string, versions []Version) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("unsupported version tag '%s', no versions are registered", constraint)
+	}
+	return fmt.Errorf("unsupported version tag '%s', the closest registered versions are %v", constraint, closestVersions(constraint, versions, 3))
+}
+
+// closestVersions returns up to n of the versions that are closest to the version in the constraint, closest
+// first. If the constraint doesn't contain a version, the highest versions are returned.
+func closestVersions(constraint string, versions []Version, n int) []Version {
+	candidates := append([]Version(nil), versions...)
+	var target *Version
+	if comparators, err := parseConstraint(constraint); err == nil {
+		target = &comparators[0].version
+	}
+	// The distance is the difference in the first component that differs, so that every version with the
+	// target's major version is closer than any version with a different major version
+	distance := func(v Version) [3]int {
+		if target == nil {
+			return [3]int{}
+		}
+		if !v.semver {
+			return [3]int{math.MaxInt32}
+		}
+		for i, d := range [3]int{v.Major - target.Major, v.Minor - target.Minor, v.Patch - target.Patch} {
+			if d != 0 {
+				var out [3]int
+				out[i] = abs(d)
+				return out
+			}
+		}
+		return [3]int{}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		di, dj := distance(candidates[i]), distance(candidates[j])
+		if di != dj {
+			for k := range di {
+				if di[k] != dj[k] {
+					return di[k] < dj[k]
+				}
+			}
+		}
+		// Higher versions are preferred when the distance is the same
+		return candidates[i].Compare(candidates[j]) > 0
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
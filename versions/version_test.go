@@ -0,0 +1,109 @@
+package versions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	v, err := ParseVersion("v4.9.3")
+	require.NoError(t, err)
+	require.Equal(t, "v4.9.3", v.String())
+	require.Equal(t, []int{4, 9, 3}, []int{v.Major, v.Minor, v.Patch})
+	require.True(t, v.IsSemver())
+
+	v, err = ParseVersion("5.0.0-beta")
+	require.NoError(t, err)
+	require.Equal(t, "beta", v.Prerelease)
+
+	for _, tag := range []string{"a", "v4.9", "v4.9.x", "v4.9.3-", "v-1.0.0"} {
+		_, err = ParseVersion(tag)
+		require.Error(t, err, tag)
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	ordered := []string{"custom", "v3.8.3", "v4.1.2", "v4.1.10", "v5.0.0-beta", "v5.0.0-rc", "v5.0.0"}
+	require.Equal(t, ordered, tags(sortVersions([]string{"v5.0.0", "v4.1.10", "v5.0.0-rc", "custom", "v4.1.2", "v5.0.0-beta", "v3.8.3"})))
+}
+
+func TestResolve(t *testing.T) {
+	versions := sortVersions([]string{"v3.8.3", "v3.9.9", "v4.1.2", "v4.1.5", "v4.2.4", "v4.7.2", "v4.9.3", "v5.0.0-beta", "custom"})
+	for constraint, expected := range map[string]string{
+		"v4.1.2":       "v4.1.2",
+		"4.1.2":        "v4.1.2",
+		"custom":       "custom",
+		"latest":       "v4.9.3",
+		"^4.2":         "v4.9.3",
+		"^3":           "v3.9.9",
+		"~4.1.4":       "v4.1.5",
+		"~4":           "v4.9.3",
+		"4.x":          "v4.9.3",
+		"4.1.x":        "v4.1.5",
+		"4.1.*":        "v4.1.5",
+		"3":            "v3.9.9",
+		"*":            "v4.9.3",
+		">=4.1 <4.7":   "v4.2.4",
+		">4.7":         "v4.9.3",
+		"<=4.1":        "v4.1.5",
+		"<4":           "v3.9.9",
+		"5.0.0-beta":   "v5.0.0-beta",
+		">=5.0.0-beta": "v5.0.0-beta",
+	} {
+		v, err := Resolve(constraint, versions)
+		require.NoError(t, err, constraint)
+		require.Equal(t, expected, v.Tag, constraint)
+	}
+
+	_, err := Resolve("^5", versions)
+	require.EqualError(t, err, "unsupported version tag '^5', the closest registered versions are [v5.0.0-beta v4.9.3 v4.7.2]")
+	_, err = Resolve("~4.3.0", versions)
+	require.EqualError(t, err, "unsupported version tag '~4.3.0', the closest registered versions are [v4.2.4 v4.1.5 v4.1.2]")
+	_, err = Resolve("abc", versions)
+	require.EqualError(t, err, "unsupported version tag 'abc', the closest registered versions are [v5.0.0-beta v4.9.3 v4.7.2]")
+	_, err = Resolve("latest", nil)
+	require.EqualError(t, err, "unsupported version tag 'latest', no versions are registered")
+
+	// Only prereleases are registered
+	v, err := Resolve("latest", sortVersions([]string{"v5.0.0-beta", "v5.0.0-rc"}))
+	require.NoError(t, err)
+	require.Equal(t, "v5.0.0-rc", v.Tag)
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	for name, r := range map[string]interface {
+		Registry
+		Resolver
+		RegisteredVersions() []Version
+	}{"caching": NewRegistry(), "expiring": NewExpiringRegistry(time.Minute)} {
+		t.Run(name, func(t *testing.T) {
+			r.Register("v4.9.3", "var a = 10;")
+			r.Register("v4.2.3", "var a = 10;")
+			r.Register("v3.9.9", "var a = 10;")
+			require.Equal(t, []string{"v3.9.9", "v4.2.3", "v4.9.3"}, tags(r.RegisteredVersions()))
+
+			tag, err := r.Resolve("^4.1")
+			require.NoError(t, err)
+			require.Equal(t, "v4.9.3", tag)
+
+			prg, err := r.Get("~4.2")
+			require.NoError(t, err)
+			exact, err := r.Get("v4.2.3")
+			require.NoError(t, err)
+			require.Same(t, exact, prg)
+
+			_, err = r.Get("^5")
+			require.EqualError(t, err, "unsupported version tag '^5', the closest registered versions are [v4.9.3 v4.2.3 v3.9.9]")
+		})
+	}
+}
+
+func tags(versions []Version) []string {
+	out := make([]string, len(versions))
+	for i, v := range versions {
+		out[i] = v.Tag
+	}
+	return out
+}